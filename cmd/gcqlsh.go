@@ -5,15 +5,56 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
+	"time"
 
+	"github.com/chzyer/readline"
 	"github.com/fatih/color"
+	"github.com/gocql/gocql"
 
+	"github.com/npenkov/gcqlsh/internal/action"
+	"github.com/npenkov/gcqlsh/internal/config"
 	"github.com/npenkov/gcqlsh/internal/db"
+	"github.com/npenkov/gcqlsh/internal/output"
 	r "github.com/npenkov/gcqlsh/internal/runtime"
 )
 
 var version string
 
+// settingFlags maps configuration settings to flags with a different name
+var settingFlags = map[string]string{"keyspace": "k"}
+
+// applySettings sets the flags that were not given on the command line from
+// the configuration files and the environment
+func applySettings(settings config.Settings) error {
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	for key, value := range settings {
+		name := key
+		if n, ok := settingFlags[key]; ok {
+			name = n
+		}
+		// an explicit -password-file also replaces a configured password
+		if explicit[name] || explicit[config.Alternatives[name]] {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %v", value, key, err)
+		}
+	}
+	return nil
+}
+
+// promptPassword reads the password from the terminal without echoing it
+func promptPassword() (string, error) {
+	fmt.Fprint(os.Stderr, "Password: ")
+	b, err := readline.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return string(b), err
+}
+
 func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
@@ -21,6 +62,7 @@ func main() {
 	var port int
 	var username string
 	var password string
+	var passwordFile string
 	var keyspace string
 	var printConf bool
 	var printCQL bool
@@ -28,21 +70,63 @@ func main() {
 	var noColor bool
 	var showVersion bool
 	var scriptFile string
+	var execute string
+	var timezone string
+	var maxVectorElements int
+	var protoVersion int
+	var ssl db.SSLConfig
+	var routing db.RoutingConfig
+	var consistency string
+	var serialConsistency string
+	var expand bool
+	var paging string
+	var format string
+	var configOpts config.Options
 
-	flag.StringVar(&host, "host", "127.0.0.1", "Cassandra host to connect to")
+	flag.StringVar(&host, "host", "127.0.0.1", "Comma separated Cassandra hosts to connect to, each may carry its own port as in host:port")
 	flag.IntVar(&port, "port", 9042, "Cassandra RPC port")
 	flag.StringVar(&username, "username", "", "Username used for the connection")
-	flag.StringVar(&password, "password", "", "Password used for the connection")
+	flag.StringVar(&password, "password", "", "Password used for the connection, visible to other users in the process list. Prefer -password-file, GCQLSH_PASSWORD or the prompt shown when only -username is given")
+	flag.StringVar(&passwordFile, "password-file", "", "File whose first line is the password used for the connection")
 	flag.BoolVar(&printConf, "print-confirmation", false, "Print 'ok' on successfuly executed cql statement from the file")
 	flag.BoolVar(&printCQL, "print-cql", false, "Print Statements that are executed from a file")
 	flag.BoolVar(&failOnError, "fail-on-error", false, "Stop execution if statement from file fails.")
 	flag.BoolVar(&noColor, "no-color", false, "Console without colors")
 	flag.StringVar(&keyspace, "k", "system", "Default keyspace to connect to")
 	flag.StringVar(&scriptFile, "f", "", "Execute file containing cql statements instead of having interacive session")
+	flag.StringVar(&execute, "e", "", "Execute the given cql statements and exit")
+	flag.StringVar(&execute, "execute", "", "Same as -e")
 	flag.BoolVar(&showVersion, "v", false, "Version information")
+	flag.IntVar(&maxVectorElements, "max-vector-elements", 0, "Number of vector elements displayed before the rest is truncated, 0 displays all")
+	flag.IntVar(&protoVersion, "protocol-version", 0, "Native protocol version to use, 0 negotiates the highest version supported by the server")
+	flag.BoolVar(&ssl.Enabled, "ssl", false, "Use TLS for the connection, implied by the other -ssl options")
+	flag.StringVar(&ssl.CAFile, "ssl-ca", "", "PEM file with the CA certificates to verify the server certificate against, system CAs by default")
+	flag.StringVar(&ssl.CertFile, "ssl-cert", "", "PEM file with the client certificate")
+	flag.StringVar(&ssl.KeyFile, "ssl-key", "", "PEM file with the private key of the client certificate")
+	flag.BoolVar(&ssl.NoVerify, "ssl-no-verify", false, "Do not verify the server certificate and host name")
+	flag.StringVar(&routing.LocalDC, "local-dc", "", "Datacenter whose nodes coordinate the statements, the others are only used when none of them is up")
+	flag.BoolVar(&routing.DiscoverPeers, "discover-peers", false, "Connect to all nodes of the cluster found through the hosts, not only to the hosts")
+	flag.StringVar(&timezone, "timezone", "UTC", "Time zone timestamps are displayed in, e.g. Europe/Sofia or Local")
+	flag.BoolVar(&expand, "expand", false, "Print every row of query results as a vertical block of columns")
+	flag.StringVar(&paging, "paging", "on", "Pause interactive query output after every page: on, off or the page size")
+	flag.StringVar(&format, "format", output.DefaultFormat, "Output format of query results: "+strings.Join(output.Formats(), ", "))
+	flag.StringVar(&consistency, "consistency", "ONE", "Consistency level of the statements")
+	flag.StringVar(&serialConsistency, "serial-consistency", "SERIAL", "Serial consistency level of lightweight transactions, SERIAL or LOCAL_SERIAL")
+	flag.StringVar(&configOpts.Profile, "profile", os.Getenv(config.EnvName("profile")), "Connection profile to use from the configuration file")
+	flag.StringVar(&configOpts.File, "config", "", "Configuration file with connection profiles (default ~/.gcqlsh/config)")
+	flag.StringVar(&configOpts.Cqlshrc, "cqlshrc", "", "cqlsh configuration file (default ~/.cassandra/cqlshrc)")
 
 	flag.Parse()
 
+	settings, err := config.Load(configOpts)
+	if err == nil {
+		err = applySettings(settings)
+	}
+	if err != nil {
+		fmt.Printf("configuration error: %v\n", err)
+		os.Exit(r.ExitUsageError)
+	}
+
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stdout, "Usage of %s:\n", os.Args[0])
 		fmt.Fprintf(os.Stdout, "%s [options] CQL_SCRIPT_FILE\n", os.Args[0])
@@ -56,27 +140,113 @@ func main() {
 
 	color.NoColor = noColor
 
+	if _, err := output.NewFormatter(format, nil, false); err != nil {
+		fmt.Println(err)
+		os.Exit(r.ExitUsageError)
+	}
+
+	pagingEnabled, pageSize, err := action.ParsePaging(paging)
+	if err != nil {
+		fmt.Printf("invalid paging %s, use on, off or the page size\n", paging)
+		os.Exit(r.ExitUsageError)
+	}
+
+	cons, err := gocql.ParseConsistencyWrapper(consistency)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(r.ExitUsageError)
+	}
+	var serialCons gocql.SerialConsistency
+	if err := serialCons.UnmarshalText([]byte(strings.ToUpper(serialConsistency))); err != nil {
+		fmt.Println(err)
+		os.Exit(r.ExitUsageError)
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		fmt.Printf("invalid time zone %s: %v\n", timezone, err)
+		os.Exit(r.ExitUsageError)
+	}
+	output.Location = location
+	output.MaxVectorElements = maxVectorElements
+	action.ShellVersion = version
+
+	var sslConfig *db.SSLConfig
+	if ssl.Enabled || ssl.CAFile != "" || ssl.CertFile != "" || ssl.KeyFile != "" || ssl.NoVerify {
+		ssl.Enabled = true
+		sslConfig = &ssl
+	}
+
+	if password != "" && passwordFile != "" {
+		fmt.Println("-password and -password-file can not be used together")
+		os.Exit(r.ExitUsageError)
+	}
+	if passwordFile != "" {
+		if password, err = config.ReadPasswordFile(passwordFile); err != nil {
+			fmt.Println(err)
+			os.Exit(r.ExitUsageError)
+		}
+	}
+	if username != "" && password == "" {
+		// scripts and piped statements leave no terminal to prompt on
+		if !readline.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Printf("no password for %s, use -password-file or %s\n", username, config.EnvName("password"))
+			os.Exit(r.ExitUsageError)
+		}
+		if password, err = promptPassword(); err != nil {
+			fmt.Println(err)
+			os.Exit(r.ExitUsageError)
+		}
+	}
+
 	// connect to the cluster
-	session, closeFunc, sesErr := db.NewSession(host, port, username, password, keyspace)
+	session, closeFunc, negotiatedVersion, sesErr := db.NewSession(host, port, username, password, keyspace, protoVersion, sslConfig, routing)
 	if sesErr != nil {
 		fmt.Println(sesErr)
-		os.Exit(-1)
+		os.Exit(r.ExitConnectionError)
 	}
+	session.SetConsistency(cons)
 
 	keyspaceSession := &db.CQLKeyspaceSession{
-		Session: session, ActiveKeyspace: keyspace, Host: host, Port: port, CloseSessionFunc: closeFunc}
+		Session: session, ActiveKeyspace: keyspace, Host: host, Port: port, CloseSessionFunc: closeFunc,
+		Username: username, Password: password,
+		ProtoVersion: negotiatedVersion, SSL: sslConfig, Routing: routing, Consistency: cons, SerialConsistency: serialCons,
+		ExpandEnabled: expand, Format: strings.ToLower(format), PagingEnabled: pagingEnabled, PageSize: pageSize}
 
 	defer func() {
-		keyspaceSession.CloseSessionFunc()
+		keyspaceSession.Close()
 	}()
 
-	if scriptFile == "" {
-		if err := r.RunInteractiveSession(keyspaceSession); err != nil {
+	opts := r.ScriptOptions{PrintCQL: printCQL, PrintConfirmation: printConf, FailOnError: failOnError}
+	var result r.ScriptResult
+	switch {
+	case execute != "":
+		color.NoColor = true
+		result = r.ProcessScript(strings.NewReader(execute), keyspaceSession, opts)
+	case scriptFile != "":
+		color.NoColor = true
+		if result, err = r.ProcessScriptFile(scriptFile, keyspaceSession, opts); err != nil {
 			fmt.Println(err)
-			os.Exit(-1)
+			keyspaceSession.Close()
+			os.Exit(r.ExitUsageError)
 		}
-	} else {
+	case !readline.IsTerminal(int(os.Stdin.Fd())):
+		// statements piped to the shell are run as a script
 		color.NoColor = true
-		r.ProcessScriptFile(scriptFile, keyspaceSession, printCQL, failOnError)
+		result = r.ProcessScript(os.Stdin, keyspaceSession, opts)
+	default:
+		if err := r.RunInteractiveSession(keyspaceSession); err != nil {
+			fmt.Println(err)
+			keyspaceSession.Close()
+			os.Exit(r.ExitExecutionError)
+		}
+		return
+	}
+
+	// the summary goes to stderr to keep machine readable output intact
+	fmt.Fprintln(os.Stderr, result)
+	if code := result.ExitCode(); code != r.ExitOK {
+		keyspaceSession.Close()
+		os.Exit(code)
 	}
 }
@@ -0,0 +1,203 @@
+package action
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gocql/gocql"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+type ringToken struct {
+	token string
+	host  *db.RingHost
+}
+
+// tokenRange is the range of tokens ending with end (inclusive) and the
+// endpoints holding a replica of it
+type tokenRange struct {
+	end       string
+	endpoints []string
+}
+
+// lessToken orders numeric tokens (Murmur3 and Random partitioners)
+// numerically and anything else, like ByteOrdered hex tokens, as strings
+func lessToken(a string, b string) bool {
+	x, okA := new(big.Int).SetString(a, 10)
+	y, okB := new(big.Int).SetString(b, 10)
+	if okA && okB {
+		return x.Cmp(y) < 0
+	}
+	return a < b
+}
+
+// replicationFactor parses a replication factor, ignoring the transient
+// replica suffix of Cassandra 4 ("3/1")
+func replicationFactor(v interface{}) int {
+	s := strings.SplitN(fmt.Sprintf("%v", v), "/", 2)[0]
+	rf, _ := strconv.Atoi(strings.TrimSpace(s))
+	return rf
+}
+
+func uniqueHosts(ring []ringToken) int {
+	seen := make(map[*db.RingHost]bool)
+	for _, t := range ring {
+		seen[t.host] = true
+	}
+	return len(seen)
+}
+
+// simpleReplicas walks the ring clockwise from position i and takes the
+// first rf distinct hosts
+func simpleReplicas(ring []ringToken, i int, rf int) []*db.RingHost {
+	replicas := make([]*db.RingHost, 0, rf)
+	seen := make(map[*db.RingHost]bool)
+	for j := 0; j < len(ring) && len(replicas) < rf; j++ {
+		h := ring[(i+j)%len(ring)].host
+		if !seen[h] {
+			seen[h] = true
+			replicas = append(replicas, h)
+		}
+	}
+	return replicas
+}
+
+// networkTopologyReplicas follows NetworkTopologyStrategy: in every data
+// center hosts on racks not used yet are preferred, hosts on already used
+// racks only fill up the remaining replicas once all racks are covered
+func networkTopologyReplicas(ring []ringToken, i int, dcRF map[string]int) []*db.RingHost {
+	racks := make(map[string]map[string]bool)
+	dcHosts := make(map[string]int)
+	seenHost := make(map[*db.RingHost]bool)
+	for _, t := range ring {
+		if racks[t.host.DataCenter] == nil {
+			racks[t.host.DataCenter] = make(map[string]bool)
+		}
+		racks[t.host.DataCenter][t.host.Rack] = true
+		if !seenHost[t.host] {
+			seenHost[t.host] = true
+			dcHosts[t.host.DataCenter]++
+		}
+	}
+
+	replicas := make([]*db.RingHost, 0)
+	taken := make(map[*db.RingHost]bool)
+	count := make(map[string]int)
+	usedRacks := make(map[string]map[string]bool)
+	skipped := make(map[string][]*db.RingHost)
+	want := func(dc string) int {
+		rf := dcRF[dc]
+		if rf > dcHosts[dc] {
+			rf = dcHosts[dc]
+		}
+		return rf
+	}
+
+	for j := 0; j < len(ring); j++ {
+		h := ring[(i+j)%len(ring)].host
+		dc := h.DataCenter
+		if taken[h] || count[dc] >= want(dc) {
+			continue
+		}
+		if usedRacks[dc] == nil {
+			usedRacks[dc] = make(map[string]bool)
+		}
+		if usedRacks[dc][h.Rack] && len(usedRacks[dc]) < len(racks[dc]) {
+			skipped[dc] = append(skipped[dc], h)
+			continue
+		}
+		taken[h] = true
+		usedRacks[dc][h.Rack] = true
+		replicas = append(replicas, h)
+		count[dc]++
+		// all racks are used now, the skipped hosts come next in ring order
+		if len(usedRacks[dc]) == len(racks[dc]) {
+			for _, s := range skipped[dc] {
+				if count[dc] >= want(dc) {
+					break
+				}
+				if !taken[s] {
+					taken[s] = true
+					replicas = append(replicas, s)
+					count[dc]++
+				}
+			}
+			skipped[dc] = nil
+		}
+	}
+	return replicas
+}
+
+// tokenRanges computes the replicas of every token range of the ring for
+// the replication settings of a keyspace
+func tokenRanges(hosts []*db.RingHost, km *gocql.KeyspaceMetadata) []tokenRange {
+	ring := make([]ringToken, 0)
+	for _, h := range hosts {
+		for _, t := range h.Tokens {
+			ring = append(ring, ringToken{token: t, host: h})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return lessToken(ring[i].token, ring[j].token) })
+
+	strategy := km.StrategyClass[strings.LastIndex(km.StrategyClass, ".")+1:]
+	dcRF := make(map[string]int)
+	for dc, v := range km.StrategyOptions {
+		if dc != "replication_factor" {
+			dcRF[dc] = replicationFactor(v)
+		}
+	}
+
+	ranges := make([]tokenRange, 0, len(ring))
+	for i, t := range ring {
+		var replicas []*db.RingHost
+		switch strategy {
+		case "SimpleStrategy":
+			replicas = simpleReplicas(ring, i, replicationFactor(km.StrategyOptions["replication_factor"]))
+		case "NetworkTopologyStrategy":
+			replicas = networkTopologyReplicas(ring, i, dcRF)
+		default:
+			// LocalStrategy, EverywhereStrategy and unknown strategies
+			replicas = simpleReplicas(ring, i, uniqueHosts(ring))
+		}
+		endpoints := make([]string, len(replicas))
+		for k, r := range replicas {
+			endpoints[k] = r.Address
+		}
+		ranges = append(ranges, tokenRange{end: t.token, endpoints: endpoints})
+	}
+	return ranges
+}
+
+func describeCluster(cks *db.CQLKeyspaceSession) error {
+	info, err := cks.FetchClusterInfo()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nCluster: %s\n", info.Name)
+	fmt.Printf("Partitioner: %s\n", info.Partitioner)
+	fmt.Printf("Snitch: %s\n", info.Snitch)
+
+	if cks.ActiveKeyspace == "" {
+		fmt.Println()
+		return nil
+	}
+	km, err := cks.KeyspaceMetadata(cks.ActiveKeyspace)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(km.StrategyClass, "LocalStrategy") {
+		fmt.Printf("\nKeyspace %s uses LocalStrategy, data is not distributed across the ring\n\n", km.Name)
+		return nil
+	}
+
+	fmt.Printf("\nRange ownership for keyspace %s:\n", km.Name)
+	for _, r := range tokenRanges(info.Hosts, km) {
+		fmt.Printf(" %39s  [%s]\n", r.end, strings.Join(r.endpoints, ", "))
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,75 @@
+package action
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func testRing() []*db.RingHost {
+	return []*db.RingHost{
+		{Address: "10.0.0.1", DataCenter: "dc1", Rack: "r1", Tokens: []string{"-100"}},
+		{Address: "10.0.0.2", DataCenter: "dc1", Rack: "r1", Tokens: []string{"0"}},
+		{Address: "10.0.0.3", DataCenter: "dc1", Rack: "r2", Tokens: []string{"100"}},
+		{Address: "10.0.0.4", DataCenter: "dc2", Rack: "r1", Tokens: []string{"-50", "50"}},
+	}
+}
+
+func TestTokenRangesSimpleStrategy(t *testing.T) {
+	km := &gocql.KeyspaceMetadata{
+		Name:            "ks",
+		StrategyClass:   "org.apache.cassandra.locator.SimpleStrategy",
+		StrategyOptions: map[string]interface{}{"replication_factor": "2"},
+	}
+
+	ranges := tokenRanges(testRing(), km)
+	expected := []string{
+		"-100 10.0.0.1,10.0.0.4",
+		"-50 10.0.0.4,10.0.0.2",
+		"0 10.0.0.2,10.0.0.4",
+		"50 10.0.0.4,10.0.0.3",
+		"100 10.0.0.3,10.0.0.1",
+	}
+
+	if len(ranges) != len(expected) {
+		t.Fatalf("Expected %d ranges, got: %v", len(expected), ranges)
+	}
+	for i, r := range ranges {
+		if got := r.end + " " + strings.Join(r.endpoints, ","); got != expected[i] {
+			t.Errorf("Expected range %d to be %q, got: %q", i, expected[i], got)
+		}
+	}
+}
+
+func TestTokenRangesNetworkTopologyStrategy(t *testing.T) {
+	km := &gocql.KeyspaceMetadata{
+		Name:            "ks",
+		StrategyClass:   "org.apache.cassandra.locator.NetworkTopologyStrategy",
+		StrategyOptions: map[string]interface{}{"dc1": "2", "dc2": "1"},
+	}
+
+	ranges := tokenRanges(testRing(), km)
+	// 10.0.0.2 shares rack r1 with 10.0.0.1, so rack r2 is preferred
+	if got := strings.Join(ranges[0].endpoints, ","); got != "10.0.0.1,10.0.0.4,10.0.0.3" {
+		t.Errorf("Expected rack aware replicas for the first range, got: %s", got)
+	}
+	for _, r := range ranges {
+		if len(r.endpoints) != 3 {
+			t.Errorf("Expected 3 replicas for range ending at %s, got: %v", r.end, r.endpoints)
+		}
+	}
+}
+
+func TestProcessCommand_DescribeCluster(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+
+	_, _, err := ProcessCommand("DESC CLUSTER;", testSession)
+	if err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+}
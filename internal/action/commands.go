@@ -0,0 +1,81 @@
+package action
+
+import (
+	"strings"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+	"github.com/npenkov/gcqlsh/internal/lexer"
+)
+
+// command runs a shell command, args is the statement following the
+// command name
+type command func(cks *db.CQLKeyspaceSession, args string) (breakLoop bool, continueLoop bool, err error)
+
+// commands are the shell commands keyed on their lowercase name. Statements
+// starting with any other word are sent to the cluster.
+var commands = map[string]command{
+	"exit":        exitCmd,
+	"quit":        exitCmd,
+	"use":         simpleCmd(useCmd),
+	"consistency": simpleCmd(consistencyCmd),
+	"copy":        simpleCmd(copyCmd),
+	"desc":        simpleCmd(describeCmd),
+	"describe":    simpleCmd(describeCmd),
+	"expand":      simpleCmd(expandCmd),
+	"format":      simpleCmd(formatCmd),
+	"login":       simpleCmd(loginCmd),
+	"paging":      simpleCmd(pagingCmd),
+	"refresh":     simpleCmd(refreshCmd),
+	"serial":      simpleCmd(serialCmd),
+	"show":        simpleCmd(showCmd),
+	"tracing":     simpleCmd(tracingCmd),
+}
+
+// simpleCmd adapts a command that neither ends nor skips the loop
+func simpleCmd(fn func(cks *db.CQLKeyspaceSession, args string) error) command {
+	return func(cks *db.CQLKeyspaceSession, args string) (bool, bool, error) {
+		return false, false, fn(cks, args)
+	}
+}
+
+// splitCommand returns the lowercase first word of a statement and the text
+// following it. name is empty when the statement holds nothing but comments
+// or does not start with a word.
+func splitCommand(cql string) (name string, args string) {
+	pos := 0
+	for _, t := range lexer.Tokenize(cql) {
+		pos += len(t.Text)
+		if !t.Significant() {
+			continue
+		}
+		if t.Kind != lexer.Word {
+			return "", ""
+		}
+		return strings.ToLower(t.Text), strings.TrimSpace(cql[pos:])
+	}
+	return "", ""
+}
+
+// matchKeywords reports whether s starts with the given keywords, compared
+// case insensitively, and returns what follows them
+func matchKeywords(s string, keywords ...string) (string, bool) {
+	for _, kw := range keywords {
+		s = strings.TrimLeft(s, " \t\n")
+		if len(s) < len(kw) || !strings.EqualFold(s[:len(kw)], kw) {
+			return "", false
+		}
+		s = s[len(kw):]
+		if s != "" && !strings.ContainsAny(s[:1], " \t\n;") {
+			return "", false
+		}
+	}
+	return strings.TrimSpace(s), true
+}
+
+func exitCmd(cks *db.CQLKeyspaceSession, args string) (bool, bool, error) {
+	return true, false, nil
+}
+
+func useCmd(cks *db.CQLKeyspaceSession, args string) error {
+	return cks.UseKeyspace(objectName(args))
+}
@@ -0,0 +1,61 @@
+package action
+
+import (
+	"testing"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		cql  string
+		name string
+		args string
+	}{
+		{cql: "Use ks;", name: "use", args: "ks;"},
+		{cql: "  describe table x", name: "describe", args: "table x"},
+		{cql: "exiting_users;", name: "exiting_users", args: ";"},
+		{cql: "-- comment\nQUIT", name: "quit", args: ""},
+		{cql: "(select)", name: "", args: ""},
+		{cql: "/* nothing */", name: "", args: ""},
+	}
+
+	for _, tt := range tests {
+		name, args := splitCommand(tt.cql)
+		if name != tt.name || args != tt.args {
+			t.Errorf("Expected splitCommand(%q) to be (%q, %q), got: (%q, %q)", tt.cql, tt.name, tt.args, name, args)
+		}
+	}
+}
+
+func TestCommandAliases(t *testing.T) {
+	for _, name := range []string{"exit", "quit", "desc", "describe", "use", "show", "tracing", "refresh"} {
+		if _, ok := commands[name]; !ok {
+			t.Errorf("Expected command %s to be registered", name)
+		}
+	}
+	if _, ok := commands["select"]; ok {
+		t.Error("Expected select to be sent to the cluster")
+	}
+}
+
+func TestMatchKeywords(t *testing.T) {
+	tests := []struct {
+		desc     string
+		keywords []string
+		rest     string
+		ok       bool
+	}{
+		{desc: "TABLE users;", keywords: []string{"table"}, rest: "users;", ok: true},
+		{desc: "tables;", keywords: []string{"table"}, ok: false},
+		{desc: "tables;", keywords: []string{"tables"}, rest: ";", ok: true},
+		{desc: "Materialized   View ks.mv", keywords: []string{"materialized", "view"}, rest: "ks.mv", ok: true},
+		{desc: "materialized views", keywords: []string{"materialized", "view"}, ok: false},
+		{desc: "full schema", keywords: []string{"schema"}, ok: false},
+	}
+
+	for _, tt := range tests {
+		rest, ok := matchKeywords(tt.desc, tt.keywords...)
+		if ok != tt.ok || rest != tt.rest {
+			t.Errorf("Expected matchKeywords(%q, %v) to be (%q, %t), got: (%q, %t)", tt.desc, tt.keywords, tt.rest, tt.ok, rest, ok)
+		}
+	}
+}
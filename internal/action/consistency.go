@@ -0,0 +1,58 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gocql/gocql"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+// ConsistencyLevels are the levels the CONSISTENCY command accepts
+var ConsistencyLevels = []string{"ANY", "ONE", "TWO", "THREE", "QUORUM", "ALL", "LOCAL_QUORUM", "EACH_QUORUM", "LOCAL_ONE"}
+
+// SerialConsistencyLevels are the levels the SERIAL CONSISTENCY command
+// accepts
+var SerialConsistencyLevels = []string{"SERIAL", "LOCAL_SERIAL"}
+
+func consistencyCmd(cks *db.CQLKeyspaceSession, level string) error {
+	level = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(level), ";"))
+	if level == "" {
+		fmt.Printf("Current consistency level is %s.\n", cks.Consistency)
+		return nil
+	}
+
+	cons, err := gocql.ParseConsistencyWrapper(level)
+	if err != nil {
+		return fmt.Errorf("Improper CONSISTENCY command, use one of %s", strings.Join(ConsistencyLevels, ", "))
+	}
+	cks.SetConsistency(cons)
+	fmt.Printf("Consistency level set to %s.\n", cons)
+	return nil
+}
+
+func serialCmd(cks *db.CQLKeyspaceSession, args string) error {
+	level, ok := matchKeywords(args, "consistency")
+	if !ok {
+		return fmt.Errorf("Improper SERIAL command, use SERIAL CONSISTENCY [level]")
+	}
+	level = strings.TrimSpace(strings.TrimSuffix(level, ";"))
+	if level == "" {
+		current := cks.SerialConsistency
+		// the server uses SERIAL when the statements do not set it
+		if current == 0 {
+			current = gocql.Serial
+		}
+		fmt.Printf("Current serial consistency level is %s.\n", current)
+		return nil
+	}
+
+	var cons gocql.SerialConsistency
+	if err := cons.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return fmt.Errorf("Improper SERIAL CONSISTENCY command, use one of %s", strings.Join(SerialConsistencyLevels, ", "))
+	}
+	cks.SetSerialConsistency(cons)
+	fmt.Printf("Serial consistency level set to %s.\n", cons)
+	return nil
+}
@@ -0,0 +1,65 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func TestConsistencyCmd(t *testing.T) {
+	cks := &db.CQLKeyspaceSession{Consistency: gocql.One}
+
+	if err := consistencyCmd(cks, ""); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := consistencyCmd(cks, "local_quorum;"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if cks.Consistency != gocql.LocalQuorum {
+		t.Errorf("Expected LOCAL_QUORUM, got: %s", cks.Consistency)
+	}
+	if err := consistencyCmd(cks, "MOST"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+	if cks.Consistency != gocql.LocalQuorum {
+		t.Errorf("Expected the level to stay LOCAL_QUORUM, got: %s", cks.Consistency)
+	}
+}
+
+func TestSerialCmd(t *testing.T) {
+	cks := &db.CQLKeyspaceSession{}
+
+	if err := serialCmd(cks, "CONSISTENCY"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := serialCmd(cks, "consistency LOCAL_SERIAL;"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if cks.SerialConsistency != gocql.LocalSerial {
+		t.Errorf("Expected LOCAL_SERIAL, got: %s", cks.SerialConsistency)
+	}
+	for _, args := range []string{"QUORUM", "consistency QUORUM"} {
+		if err := serialCmd(cks, args); err == nil {
+			t.Errorf("Expected an error for SERIAL %s", args)
+		}
+	}
+}
+
+func TestTracerQuery_Consistency(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+	defer testSession.SetConsistency(testSession.Consistency)
+
+	if _, _, err := ProcessCommand("CONSISTENCY QUORUM;", testSession); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	tracer := NewTracer(testSession)
+	defer tracer.Close()
+	if cons := tracer.Query("SELECT * FROM users").GetConsistency(); cons != gocql.Quorum {
+		t.Errorf("Expected QUORUM, got: %s", cons)
+	}
+}
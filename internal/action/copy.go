@@ -0,0 +1,333 @@
+package action
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+	"github.com/npenkov/gcqlsh/internal/lexer"
+)
+
+// copyStmt is a parsed COPY command
+type copyStmt struct {
+	keyspace string
+	table    string
+	// columns are the listed columns, all columns of the table when empty
+	columns []string
+	// from is set for COPY FROM, which imports the file
+	from bool
+	// file is the file path, or STDIN / STDOUT
+	file    string
+	options copyOptions
+}
+
+// copyOptions are the WITH options of a COPY command keyed on their
+// lowercase name
+type copyOptions map[string]string
+
+// parseCopy parses the arguments of
+//
+//	COPY [ks.]table [(col, ...)] TO|FROM 'file'|STDOUT|STDIN [WITH opt = value [AND ...]]
+func parseCopy(args string, defaultKeyspace string) (*copyStmt, error) {
+	tokens := make([]lexer.Token, 0)
+	for _, t := range lexer.Tokenize(args) {
+		if t.Significant() {
+			tokens = append(tokens, t)
+		}
+	}
+	if n := len(tokens); n > 0 && tokens[n-1].Text == ";" {
+		tokens = tokens[:n-1]
+	}
+
+	p := &copyParser{tokens: tokens}
+	stmt := &copyStmt{keyspace: defaultKeyspace, options: copyOptions{}}
+
+	name, ok := p.identifier()
+	if !ok {
+		return nil, p.errorf("table name")
+	}
+	stmt.table = name
+	if p.symbol(".") {
+		if stmt.table, ok = p.identifier(); !ok {
+			return nil, p.errorf("table name")
+		}
+		stmt.keyspace = name
+	}
+
+	if p.symbol("(") {
+		for {
+			col, ok := p.identifier()
+			if !ok {
+				return nil, p.errorf("column name")
+			}
+			stmt.columns = append(stmt.columns, col)
+			if p.symbol(")") {
+				break
+			}
+			if !p.symbol(",") {
+				return nil, p.errorf(", or )")
+			}
+		}
+	}
+
+	switch {
+	case p.keyword("to"):
+	case p.keyword("from"):
+		stmt.from = true
+	default:
+		return nil, p.errorf("TO or FROM")
+	}
+
+	t, ok := p.next()
+	switch {
+	case ok && t.Kind == lexer.String && t.Complete:
+		stmt.file = expandHome(unquoteString(t.Text))
+	case ok && (t.Is("stdout") && !stmt.from || t.Is("stdin") && stmt.from):
+		stmt.file = strings.ToUpper(t.Text)
+	default:
+		return nil, p.errorf("file name")
+	}
+
+	if p.keyword("with") {
+		for {
+			opt, ok := p.next()
+			if !ok || opt.Kind != lexer.Word {
+				return nil, p.errorf("option name")
+			}
+			if !p.symbol("=") {
+				return nil, p.errorf("=")
+			}
+			value, ok := p.next()
+			if !ok || value.Kind != lexer.Word && value.Kind != lexer.String {
+				return nil, p.errorf("option value")
+			}
+			if value.Kind == lexer.String {
+				value.Text = unquoteString(value.Text)
+			}
+			stmt.options[strings.ToLower(opt.Text)] = value.Text
+			if !p.keyword("and") {
+				break
+			}
+		}
+	}
+
+	if t, ok := p.next(); ok {
+		return nil, fmt.Errorf("Improper COPY command, unexpected %s", t.Text)
+	}
+	return stmt, nil
+}
+
+// copyParser walks the significant tokens of a COPY command
+type copyParser struct {
+	tokens []lexer.Token
+	pos    int
+}
+
+func (p *copyParser) next() (lexer.Token, bool) {
+	if p.pos >= len(p.tokens) {
+		return lexer.Token{}, false
+	}
+	p.pos++
+	return p.tokens[p.pos-1], true
+}
+
+func (p *copyParser) peek() (lexer.Token, bool) {
+	if p.pos >= len(p.tokens) {
+		return lexer.Token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *copyParser) symbol(s string) bool {
+	if t, ok := p.peek(); ok && t.Kind == lexer.Symbol && t.Text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *copyParser) keyword(kw string) bool {
+	if t, ok := p.peek(); ok && t.Is(kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// identifier reads a name, lowercasing it unless it is quoted
+func (p *copyParser) identifier() (string, bool) {
+	t, ok := p.peek()
+	if !ok || t.Kind != lexer.Word && (t.Kind != lexer.QuotedIdentifier || !t.Complete) {
+		return "", false
+	}
+	p.pos++
+	return objectName(t.Text), true
+}
+
+func (p *copyParser) errorf(expected string) error {
+	if t, ok := p.peek(); ok {
+		return fmt.Errorf("Improper COPY command, expected %s at %s", expected, t.Text)
+	}
+	return fmt.Errorf("Improper COPY command, expected %s", expected)
+}
+
+// unquoteString returns the text of a single quoted string literal
+func unquoteString(s string) string {
+	return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+}
+
+// expandHome expands a leading ~ of a path as cqlsh does
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+func copyCmd(cks *db.CQLKeyspaceSession, args string) error {
+	stmt, err := parseCopy(args, cks.ActiveKeyspace)
+	if err != nil {
+		return err
+	}
+	if stmt.from {
+		return copyFrom(cks, stmt)
+	}
+	return copyTo(cks, stmt)
+}
+
+// check rejects the options that are not known
+func (o copyOptions) check(known ...string) error {
+	unknown := make([]string, 0)
+	for name := range o {
+		if !contains(known, name) {
+			unknown = append(unknown, strings.ToUpper(name))
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("Unrecognized COPY options: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func (o copyOptions) str(name string, def string) string {
+	if v, ok := o[name]; ok {
+		return v
+	}
+	return def
+}
+
+func (o copyOptions) boolean(name string, def bool) (bool, error) {
+	v, ok := o[name]
+	if !ok {
+		return def, nil
+	}
+	switch strings.ToLower(v) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("Invalid value %s for COPY option %s, expected a boolean", v, strings.ToUpper(name))
+}
+
+// positive reads an integer option that has to be greater than zero
+func (o copyOptions) positive(name string, def int) (int, error) {
+	v, ok := o[name]
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("Invalid value %s for COPY option %s, expected a positive number", v, strings.ToUpper(name))
+	}
+	return n, nil
+}
+
+// limit reads a maximum number of errors, -1 for no limit
+func (o copyOptions) limit(name string, def int) (int, error) {
+	v, ok := o[name]
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < -1 {
+		return 0, fmt.Errorf("Invalid value %s for COPY option %s, expected a number or -1", v, strings.ToUpper(name))
+	}
+	return n, nil
+}
+
+// char reads an option of a single character, like a delimiter
+func (o copyOptions) char(name string, def rune) (rune, error) {
+	v, ok := o[name]
+	if !ok {
+		return def, nil
+	}
+	if v == `\t` {
+		return '\t', nil
+	}
+	r := []rune(v)
+	if len(r) != 1 {
+		return 0, fmt.Errorf("Invalid value %s for COPY option %s, expected a single character", v, strings.ToUpper(name))
+	}
+	return r[0], nil
+}
+
+// defaultCopyWorkers leaves a core to the shell as cqlsh does, with at most
+// 16 workers
+func defaultCopyWorkers() int {
+	n := runtime.NumCPU() - 1
+	if n > 16 {
+		n = 16
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// progressInterval is the time between progress reports of COPY
+const progressInterval = time.Second
+
+// copyProgress reports the rows processed by COPY and their rate
+type copyProgress struct {
+	w        io.Writer
+	start    time.Time
+	last     time.Time
+	lastRows int
+}
+
+func newCopyProgress(w io.Writer) *copyProgress {
+	now := time.Now()
+	return &copyProgress{w: w, start: now, last: now}
+}
+
+// update reports the progress when the interval has passed since the last
+// report, rows is the number of rows processed so far
+func (p *copyProgress) update(rows int) {
+	now := time.Now()
+	if now.Sub(p.last) < progressInterval {
+		return
+	}
+	rate := float64(rows-p.lastRows) / now.Sub(p.last).Seconds()
+	fmt.Fprintf(p.w, "\rProcessed: %d rows; Rate: %7.0f rows/s; Avg. rate: %7.0f rows/s",
+		rows, rate, float64(rows)/now.Sub(p.start).Seconds())
+	p.last, p.lastRows = now, rows
+}
+
+// finish reports the final count and rate, e.g. "10 rows exported to
+// users.csv in 0.214 seconds."
+func (p *copyProgress) finish(rows int, done string, file string) {
+	elapsed := time.Since(p.start)
+	fmt.Fprintf(p.w, "\rProcessed: %d rows; Avg. rate: %7.0f rows/s\n", rows, float64(rows)/elapsed.Seconds())
+	fmt.Fprintf(p.w, "%d rows %s %s in %.3f seconds.\n", rows, done, file, elapsed.Seconds())
+}
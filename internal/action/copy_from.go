@@ -0,0 +1,585 @@
+package action
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+	"github.com/npenkov/gcqlsh/internal/output"
+)
+
+const (
+	// defaultChunkSize is the number of records a worker of COPY FROM
+	// converts and writes at once
+	defaultChunkSize = 1000
+	// defaultMaxBatchSize is the number of rows of a partition that are
+	// written in one unlogged batch
+	defaultMaxBatchSize    = 20
+	defaultMaxInsertErrors = 1000
+	defaultMaxAttempts     = 5
+)
+
+// importRecord is a record of the imported file
+type importRecord struct {
+	// num is the number of the record, starting at 1 after the header
+	num int
+	// fields are the values of a CSV record
+	fields []string
+	// line is a NDJSON record
+	line string
+}
+
+// importChunk is a run of records imported by one worker
+type importChunk struct {
+	index   int
+	records []importRecord
+}
+
+// rejectedRecord is a record that could not be imported
+type rejectedRecord struct {
+	record importRecord
+	err    error
+	// parse is set when the record could not be converted, otherwise it was
+	// rejected by the cluster
+	parse bool
+}
+
+type chunkResult struct {
+	index int
+	// last is the number of the last record of the chunk
+	last     int
+	imported int
+	rejected []rejectedRecord
+}
+
+// importer converts records to rows and writes them
+type importer struct {
+	session *gocql.Session
+	insert  string
+	cols    []gocql.ColumnInfo
+	// keys are the indexes of the partition key columns, primary those of
+	// all primary key columns
+	keys        []int
+	primary     []int
+	ndjson      bool
+	null        string
+	maxBatch    int
+	maxAttempts int
+}
+
+// copyFrom imports a file into a table, chunks of records are converted and
+// written by a pool of workers
+func copyFrom(cks *db.CQLKeyspaceSession, stmt *copyStmt) error {
+	opts := stmt.options
+	if err := opts.check("header", "delimiter", "null", "format", "numprocesses", "chunksize", "maxbatchsize",
+		"maxparseerrors", "maxinserterrors", "maxattempts", "errfile", "checkpoint"); err != nil {
+		return err
+	}
+	header, err := opts.boolean("header", false)
+	if err != nil {
+		return err
+	}
+	delimiter, err := opts.char("delimiter", ',')
+	if err != nil {
+		return err
+	}
+	format := strings.ToLower(opts.str("format", "csv"))
+	if format != "csv" && format != "ndjson" {
+		return fmt.Errorf("Invalid value %s for COPY option FORMAT, expected csv or ndjson", format)
+	}
+	workers, err := opts.positive("numprocesses", defaultCopyWorkers())
+	if err != nil {
+		return err
+	}
+	chunkSize, err := opts.positive("chunksize", defaultChunkSize)
+	if err != nil {
+		return err
+	}
+	maxBatch, err := opts.positive("maxbatchsize", defaultMaxBatchSize)
+	if err != nil {
+		return err
+	}
+	maxAttempts, err := opts.positive("maxattempts", defaultMaxAttempts)
+	if err != nil {
+		return err
+	}
+	maxParseErrors, err := opts.limit("maxparseerrors", -1)
+	if err != nil {
+		return err
+	}
+	maxInsertErrors, err := opts.limit("maxinserterrors", defaultMaxInsertErrors)
+	if err != nil {
+		return err
+	}
+	errFile := expandHome(opts.str("errfile", fmt.Sprintf("import_%s_%s.err", stmt.keyspace, stmt.table)))
+	checkpoint := expandHome(opts.str("checkpoint", ""))
+
+	im, err := newImporter(cks, stmt)
+	if err != nil {
+		return err
+	}
+	im.ndjson, im.null, im.maxBatch, im.maxAttempts = format == "ndjson", opts.str("null", ""), maxBatch, maxAttempts
+
+	in, report := io.Reader(os.Stdin), os.Stdout
+	if stmt.file != "STDIN" {
+		f, err := os.Open(stmt.file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	next := ndjsonRecords(in)
+	if format == "csv" {
+		next = csvRecords(in, delimiter, header)
+	}
+
+	skip := 0
+	if checkpoint != "" {
+		if skip, err = readCheckpoint(checkpoint); err != nil {
+			return err
+		}
+		if skip > 0 {
+			fmt.Fprintf(report, "Resuming after record %d from checkpoint %s\n", skip, checkpoint)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chunks := make(chan importChunk)
+	results := make(chan chunkResult, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				results <- im.importChunk(ctx, chunk)
+			}
+		}()
+	}
+	var readErr error
+	go func() {
+		defer close(chunks)
+		readErr = readChunks(ctx, next, skip, chunkSize, chunks)
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	progress := newCopyProgress(report)
+	rejects := &rejectWriter{file: errFile, delimiter: delimiter, resume: skip > 0}
+	defer rejects.close()
+	var abortErr error
+	imported, processed, parseErrors, insertErrors := 0, 0, 0, 0
+	done := make(map[int]int)
+	nextChunk, committed := 0, skip
+	for res := range results {
+		// the chunks still in flight after an abort are dropped
+		if abortErr != nil {
+			continue
+		}
+		imported += res.imported
+		processed += res.imported + len(res.rejected)
+		for _, r := range res.rejected {
+			if r.parse {
+				parseErrors++
+			} else {
+				insertErrors++
+			}
+			output.PrintError(fmt.Sprintf("Failed to import record %d: %v", r.record.num, r.err))
+			if err := rejects.write(r.record); err != nil && abortErr == nil {
+				abortErr = err
+			}
+		}
+		progress.update(processed)
+
+		if abortErr == nil && maxParseErrors >= 0 && parseErrors > maxParseErrors {
+			abortErr = fmt.Errorf("Exceeded maximum number of parse errors %d", maxParseErrors)
+		}
+		if abortErr == nil && maxInsertErrors >= 0 && insertErrors > maxInsertErrors {
+			abortErr = fmt.Errorf("Exceeded maximum number of insert errors %d", maxInsertErrors)
+		}
+		if abortErr != nil {
+			cancel()
+			continue
+		}
+
+		// the checkpoint is the last record of the chunks done without gaps
+		done[res.index] = res.last
+		advanced := false
+		for last, ok := done[nextChunk]; ok; last, ok = done[nextChunk] {
+			delete(done, nextChunk)
+			committed, nextChunk, advanced = last, nextChunk+1, true
+		}
+		if advanced && checkpoint != "" {
+			if err := writeCheckpoint(checkpoint, committed); err != nil {
+				abortErr = err
+				cancel()
+			}
+		}
+	}
+
+	if abortErr == nil {
+		abortErr = readErr
+	}
+	if abortErr != nil {
+		if checkpoint != "" {
+			fmt.Fprintf(report, "\nImport stopped, run the same COPY command to resume after record %d\n", committed)
+		}
+		return abortErr
+	}
+	if checkpoint != "" {
+		if err := os.Remove(checkpoint); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	progress.finish(imported, "imported from", stmt.file)
+	if rejected := parseErrors + insertErrors; rejected > 0 {
+		fmt.Fprintf(report, "%d rows failed to import, they were written to %s\n", rejected, errFile)
+	}
+	return nil
+}
+
+// newImporter prepares the import of the listed columns of a table, or of
+// all of them when none are listed
+func newImporter(cks *db.CQLKeyspaceSession, stmt *copyStmt) (*importer, error) {
+	columns, err := cks.FetchTableColumns(stmt.keyspace, stmt.table)
+	if err != nil {
+		return nil, err
+	}
+	for _, col := range stmt.columns {
+		if _, ok := columns[col]; !ok {
+			return nil, fmt.Errorf("Column %s not in table %s.%s", col, stmt.keyspace, stmt.table)
+		}
+	}
+
+	// the result metadata describes user types and vectors, which the schema
+	// metadata of the driver does not
+	cols := "*"
+	if len(stmt.columns) > 0 {
+		quoted := make([]string, len(stmt.columns))
+		for i, col := range stmt.columns {
+			quoted[i] = quoteIdent(col)
+		}
+		cols = strings.Join(quoted, ", ")
+	}
+	table := qualifiedName(stmt.keyspace, stmt.table)
+	iter := cks.Session.Query(fmt.Sprintf("SELECT %s FROM %s LIMIT 1", cols, table)).Iter()
+	im := &importer{session: cks.Session, cols: iter.Columns()}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(im.cols))
+	marks := make([]string, len(im.cols))
+	listed := make(map[string]bool, len(im.cols))
+	for i, col := range im.cols {
+		names[i], marks[i], listed[col.Name] = quoteIdent(col.Name), "?", true
+		switch columns[col.Name].Kind {
+		case gocql.ColumnPartitionKey:
+			im.keys = append(im.keys, i)
+			im.primary = append(im.primary, i)
+		case gocql.ColumnClusteringKey:
+			im.primary = append(im.primary, i)
+		}
+	}
+	for _, col := range columns {
+		if (col.Kind == gocql.ColumnPartitionKey || col.Kind == gocql.ColumnClusteringKey) && !listed[col.Name] {
+			return nil, fmt.Errorf("Primary key column %s is missing", col.Name)
+		}
+	}
+	sort.Slice(im.keys, func(i, j int) bool {
+		return columns[im.cols[im.keys[i]].Name].ComponentIndex < columns[im.cols[im.keys[j]].Name].ComponentIndex
+	})
+	im.insert = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(names, ", "), strings.Join(marks, ", "))
+	return im, nil
+}
+
+// importChunk converts the records of a chunk and writes them in batches of
+// rows of the same partition
+func (im *importer) importChunk(ctx context.Context, chunk importChunk) chunkResult {
+	res := chunkResult{index: chunk.index, last: chunk.records[len(chunk.records)-1].num}
+
+	type partition struct {
+		records []importRecord
+		rows    [][]interface{}
+	}
+	partitions := make(map[string]*partition)
+	order := make([]string, 0)
+	for _, rec := range chunk.records {
+		row, key, err := im.convert(rec)
+		if err != nil {
+			res.rejected = append(res.rejected, rejectedRecord{record: rec, err: err, parse: true})
+			continue
+		}
+		p, ok := partitions[key]
+		if !ok {
+			p = &partition{}
+			partitions[key] = p
+			order = append(order, key)
+		}
+		p.records = append(p.records, rec)
+		p.rows = append(p.rows, row)
+	}
+
+	for _, key := range order {
+		p := partitions[key]
+		for start := 0; start < len(p.rows); start += im.maxBatch {
+			end := start + im.maxBatch
+			if end > len(p.rows) {
+				end = len(p.rows)
+			}
+			if err := im.write(ctx, p.rows[start:end]); err != nil {
+				for _, rec := range p.records[start:end] {
+					res.rejected = append(res.rejected, rejectedRecord{record: rec, err: err})
+				}
+				continue
+			}
+			res.imported += end - start
+		}
+	}
+	return res
+}
+
+// convert turns a record into the values of a row and the key of its
+// partition
+func (im *importer) convert(rec importRecord) ([]interface{}, string, error) {
+	row := make([]interface{}, len(im.cols))
+	if im.ndjson {
+		dec := json.NewDecoder(strings.NewReader(rec.line))
+		dec.UseNumber()
+		var obj map[string]interface{}
+		if err := dec.Decode(&obj); err != nil {
+			return nil, "", err
+		}
+		found := 0
+		for i, col := range im.cols {
+			v, ok := obj[col.Name]
+			if !ok {
+				continue
+			}
+			found++
+			var err error
+			if row[i], err = jsonField(col.TypeInfo, v); err != nil {
+				return nil, "", fmt.Errorf("column %s: %v", col.Name, err)
+			}
+		}
+		if found != len(obj) {
+			return nil, "", fmt.Errorf("the record has keys that are not imported columns")
+		}
+	} else {
+		if len(rec.fields) != len(im.cols) {
+			return nil, "", fmt.Errorf("expected %d fields, got %d", len(im.cols), len(rec.fields))
+		}
+		for i, col := range im.cols {
+			if rec.fields[i] == im.null {
+				continue
+			}
+			var err error
+			if row[i], err = parseField(col.TypeInfo, rec.fields[i]); err != nil {
+				return nil, "", fmt.Errorf("column %s: %v", col.Name, err)
+			}
+		}
+	}
+
+	for _, i := range im.primary {
+		if row[i] == nil {
+			return nil, "", fmt.Errorf("primary key column %s is null", im.cols[i].Name)
+		}
+	}
+	var key []byte
+	for _, i := range im.keys {
+		data, err := gocql.Marshal(im.cols[i].TypeInfo, row[i])
+		if err != nil {
+			return nil, "", fmt.Errorf("column %s: %v", im.cols[i].Name, err)
+		}
+		key = binary.AppendUvarint(key, uint64(len(data)))
+		key = append(key, data...)
+	}
+	return row, string(key), nil
+}
+
+// write inserts rows of a partition, retrying failed attempts
+func (im *importer) write(ctx context.Context, rows [][]interface{}) error {
+	for attempt := 1; ; attempt++ {
+		var err error
+		if len(rows) == 1 {
+			err = im.session.Query(im.insert, rows[0]...).WithContext(ctx).Exec()
+		} else {
+			batch := im.session.NewBatch(gocql.UnloggedBatch).WithContext(ctx)
+			for _, row := range rows {
+				batch.Query(im.insert, row...)
+			}
+			err = im.session.ExecuteBatch(batch)
+		}
+		if err == nil || attempt >= im.maxAttempts || ctx.Err() != nil {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+	}
+}
+
+// readChunks groups the records after the first skip ones into chunks
+func readChunks(ctx context.Context, next func() (importRecord, error), skip int, size int, chunks chan<- importChunk) error {
+	chunk := importChunk{}
+	send := func() bool {
+		select {
+		case chunks <- chunk:
+			chunk = importChunk{index: chunk.index + 1}
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for {
+		rec, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if rec.num <= skip {
+			continue
+		}
+		chunk.records = append(chunk.records, rec)
+		if len(chunk.records) == size && !send() {
+			return nil
+		}
+	}
+	if len(chunk.records) > 0 {
+		send()
+	}
+	return nil
+}
+
+// csvRecords reads CSV records, skipping the header line
+func csvRecords(r io.Reader, delimiter rune, header bool) func() (importRecord, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = delimiter
+	// the number of fields is checked for every record, so that a wrong
+	// one rejects the record instead of stopping the import
+	cr.FieldsPerRecord = -1
+	num := 0
+	return func() (importRecord, error) {
+		fields, err := cr.Read()
+		if err != nil {
+			return importRecord{}, err
+		}
+		if header {
+			header = false
+			if fields, err = cr.Read(); err != nil {
+				return importRecord{}, err
+			}
+		}
+		num++
+		return importRecord{num: num, fields: fields}, nil
+	}
+}
+
+// ndjsonRecords reads one JSON object per line, skipping blank lines
+func ndjsonRecords(r io.Reader) func() (importRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	num := 0
+	return func() (importRecord, error) {
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.TrimSpace(line) != "" {
+				num++
+				return importRecord{num: num, line: line}, nil
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return importRecord{}, err
+		}
+		return importRecord{}, io.EOF
+	}
+}
+
+// rejectWriter writes rejected records to the error file as they were read,
+// the file is created for the first one
+type rejectWriter struct {
+	file      string
+	delimiter rune
+	// resume appends to the file, keeping the records rejected by the run
+	// that was interrupted
+	resume bool
+	f      *os.File
+	csv    *csv.Writer
+}
+
+func (w *rejectWriter) write(rec importRecord) error {
+	if w.f == nil {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if w.resume {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		f, err := os.OpenFile(w.file, flags, 0644)
+		if err != nil {
+			return err
+		}
+		w.f, w.csv = f, csv.NewWriter(f)
+		w.csv.Comma = w.delimiter
+	}
+	if rec.fields == nil {
+		_, err := io.WriteString(w.f, rec.line+"\n")
+		return err
+	}
+	if err := w.csv.Write(rec.fields); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *rejectWriter) close() {
+	if w.f != nil {
+		w.f.Close()
+	}
+}
+
+// readCheckpoint returns the number of records imported by an earlier run,
+// 0 when there is no checkpoint file
+func readCheckpoint(file string) (int, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid checkpoint file %s", file)
+	}
+	return n, nil
+}
+
+// writeCheckpoint replaces the checkpoint file, so that a crash leaves the
+// old or the new one
+func writeCheckpoint(file string, records int) error {
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(records)+"\n"), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
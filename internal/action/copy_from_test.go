@@ -0,0 +1,170 @@
+package action
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+)
+
+func TestCSVRecords(t *testing.T) {
+	next := csvRecords(strings.NewReader("id|name\n1|\"a|b\"\n2\n"), '|', true)
+
+	expected := []importRecord{
+		{num: 1, fields: []string{"1", "a|b"}},
+		{num: 2, fields: []string{"2"}},
+	}
+	for _, exp := range expected {
+		rec, err := next()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !reflect.DeepEqual(rec, exp) {
+			t.Errorf("Expected %+v, got: %+v", exp, rec)
+		}
+	}
+	if _, err := next(); err != io.EOF {
+		t.Errorf("Expected EOF, got: %v", err)
+	}
+}
+
+func TestReadChunks(t *testing.T) {
+	next := ndjsonRecords(strings.NewReader("{\"id\": 1}\n\n{\"id\": 2}\n{\"id\": 3}\n{\"id\": 4}\n"))
+	chunks := make(chan importChunk, 10)
+	if err := readChunks(context.Background(), next, 1, 2, chunks); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	close(chunks)
+
+	got := make([][]int, 0)
+	for chunk := range chunks {
+		nums := make([]int, 0)
+		for _, rec := range chunk.records {
+			nums = append(nums, rec.num)
+		}
+		if chunk.index != len(got) {
+			t.Errorf("Expected chunk %d, got: %d", len(got), chunk.index)
+		}
+		got = append(got, nums)
+	}
+	if !reflect.DeepEqual(got, [][]int{{2, 3}, {4}}) {
+		t.Errorf("Expected records [[2 3] [4]] after skipping one, got: %v", got)
+	}
+}
+
+func TestImporterConvert(t *testing.T) {
+	im := &importer{
+		cols: []gocql.ColumnInfo{
+			{Name: "id", TypeInfo: nativeType(gocql.TypeInt)},
+			{Name: "name", TypeInfo: nativeType(gocql.TypeText)},
+		},
+		keys:    []int{0},
+		primary: []int{0},
+		null:    "NULL",
+	}
+
+	row, key, err := im.convert(importRecord{fields: []string{"7", "NULL"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !reflect.DeepEqual(row, []interface{}{int32(7), nil}) {
+		t.Errorf("Expected [7 <nil>], got: %v", row)
+	}
+	_, other, _ := im.convert(importRecord{fields: []string{"8", "x"}})
+	_, same, _ := im.convert(importRecord{fields: []string{"7", "y"}})
+	if key == other || key != same {
+		t.Errorf("Expected rows to be grouped by partition key")
+	}
+
+	for _, fields := range [][]string{{"7"}, {"x", "a"}, {"NULL", "a"}} {
+		if _, _, err := im.convert(importRecord{fields: fields}); err == nil {
+			t.Errorf("Expected an error for %v", fields)
+		}
+	}
+
+	im.ndjson = true
+	if row, _, err := im.convert(importRecord{line: `{"id": 3, "name": "n"}`}); err != nil || !reflect.DeepEqual(row, []interface{}{int32(3), "n"}) {
+		t.Errorf("Expected [3 n], got: %v %v", row, err)
+	}
+	if _, _, err := im.convert(importRecord{line: `{"id": 3, "other": 1}`}); err == nil {
+		t.Error("Expected an error for an unknown column")
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "import.checkpoint")
+
+	if n, err := readCheckpoint(file); err != nil || n != 0 {
+		t.Errorf("Expected 0 without a checkpoint, got: %d %v", n, err)
+	}
+	if err := writeCheckpoint(file, 2000); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if n, err := readCheckpoint(file); err != nil || n != 2000 {
+		t.Errorf("Expected 2000, got: %d %v", n, err)
+	}
+}
+
+func TestRejectWriterResume(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "import.err")
+	if err := os.WriteFile(file, []byte("stale\n"), 0644); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	first := &rejectWriter{file: file, delimiter: ','}
+	if err := first.write(importRecord{num: 1, fields: []string{"1", "a"}}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	first.close()
+
+	resumed := &rejectWriter{file: file, delimiter: ',', resume: true}
+	if err := resumed.write(importRecord{num: 9, line: `{"id": 9}`}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	resumed.close()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if expected := "1,a\n{\"id\": 9}\n"; string(data) != expected {
+		t.Errorf("Expected %q, got: %q", expected, string(data))
+	}
+}
+
+func TestCopyFrom(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "products.csv")
+	errFile := filepath.Join(dir, "products.err")
+	data := "id,name,price,stock\n" +
+		"6ab09bec-e68e-48d9-a5f8-97e6fb4c9b47,Imported,2.5,7\n" +
+		"6ab09bec-e68e-48d9-a5f8-97e6fb4c9b48,Broken,cheap,1\n"
+	if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := "COPY test_keyspace.products (id, name, price, stock) FROM '" + file +
+		"' WITH HEADER = true AND ERRFILE = '" + errFile + "';"
+	if _, _, err := ProcessCommand(cmd, testSession); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var name string
+	if err := testSession.Session.Query("SELECT name FROM test_keyspace.products WHERE id = 6ab09bec-e68e-48d9-a5f8-97e6fb4c9b47").Scan(&name); err != nil || name != "Imported" {
+		t.Errorf("Expected the imported product, got: %q %v", name, err)
+	}
+	rejected, err := os.ReadFile(errFile)
+	if err != nil || !strings.Contains(string(rejected), "Broken") {
+		t.Errorf("Expected the broken row in the error file, got: %q %v", rejected, err)
+	}
+}
@@ -0,0 +1,118 @@
+package action
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCopy(t *testing.T) {
+	tests := []struct {
+		args     string
+		expected copyStmt
+	}{
+		{
+			args:     "users TO 'users.csv';",
+			expected: copyStmt{keyspace: "active", table: "users", file: "users.csv", options: copyOptions{}},
+		},
+		{
+			args: `Ks."Users" (id, "Name") TO 'it''s.csv' WITH HEADER = true AND delimiter='|'`,
+			expected: copyStmt{keyspace: "ks", table: "Users", columns: []string{"id", "Name"}, file: "it's.csv",
+				options: copyOptions{"header": "true", "delimiter": "|"}},
+		},
+		{
+			args:     "ks.users TO stdout WITH FORMAT='ndjson';",
+			expected: copyStmt{keyspace: "ks", table: "users", file: "STDOUT", options: copyOptions{"format": "ndjson"}},
+		},
+		{
+			args:     "users FROM 'users.csv'",
+			expected: copyStmt{keyspace: "active", table: "users", from: true, file: "users.csv", options: copyOptions{}},
+		},
+	}
+
+	for _, tt := range tests {
+		stmt, err := parseCopy(tt.args, "active")
+		if err != nil {
+			t.Errorf("Expected no error for %q, got: %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(*stmt, tt.expected) {
+			t.Errorf("Expected %q to parse into %+v, got: %+v", tt.args, tt.expected, *stmt)
+		}
+	}
+}
+
+func TestParseCopyErrors(t *testing.T) {
+	for _, args := range []string{
+		"",
+		"users",
+		"users INTO 'users.csv'",
+		"users (id TO 'users.csv'",
+		"users TO users.csv",
+		"users TO STDIN",
+		"users TO 'users.csv' WITH HEADER",
+		"users TO 'users.csv' extra",
+	} {
+		if _, err := parseCopy(args, "active"); err == nil {
+			t.Errorf("Expected an error for %q", args)
+		}
+	}
+}
+
+func TestCopyOptions(t *testing.T) {
+	opts := copyOptions{"header": "yes", "delimiter": `\t`, "pagesize": "0", "numprocesses": "4", "null": "NULL"}
+
+	if err := opts.check("header", "delimiter", "pagesize", "numprocesses"); err == nil || err.Error() != "Unrecognized COPY options: NULL" {
+		t.Errorf("Expected NULL to be unrecognized, got: %v", err)
+	}
+	if header, err := opts.boolean("header", false); err != nil || !header {
+		t.Errorf("Expected HEADER to be true, got: %t %v", header, err)
+	}
+	if delimiter, err := opts.char("delimiter", ','); err != nil || delimiter != '\t' {
+		t.Errorf("Expected a tab delimiter, got: %q %v", delimiter, err)
+	}
+	if _, err := opts.positive("pagesize", 1000); err == nil {
+		t.Error("Expected an error for PAGESIZE 0")
+	}
+	if n, err := opts.positive("numprocesses", 1); err != nil || n != 4 {
+		t.Errorf("Expected 4 workers, got: %d %v", n, err)
+	}
+	if n, err := opts.positive("maxrequests", 6); err != nil || n != 6 {
+		t.Errorf("Expected the default for a missing option, got: %d %v", n, err)
+	}
+}
+
+func TestCopyTo(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+
+	file := filepath.Join(t.TempDir(), "users.csv")
+	cmd := "COPY test_keyspace.users (name, email, age) TO '" + file + "' WITH HEADER = true AND NUMPROCESSES = 2;"
+	if _, _, err := ProcessCommand(cmd, testSession); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("Expected the export file, got: %v", err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV, got: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected a header and one row, got: %v", records)
+	}
+	if strings.Join(records[0], ",") != "name,email,age" {
+		t.Errorf("Expected header name,email,age, got: %v", records[0])
+	}
+	if strings.Join(records[1], ",") != "John Doe,john@example.com,30" {
+		t.Errorf("Expected the test user, got: %v", records[1])
+	}
+}
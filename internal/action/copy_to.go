@@ -0,0 +1,258 @@
+package action
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gocql/gocql"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+	"github.com/npenkov/gcqlsh/internal/output"
+)
+
+const (
+	// defaultCopyPageSize is the number of rows fetched at once by COPY
+	defaultCopyPageSize = 1000
+	// rangesPerWorker is the number of token ranges scanned by every worker
+	// of COPY TO, smaller ranges spread uneven data better between them
+	rangesPerWorker = 16
+)
+
+// exportPage is a page of rows read from a token range
+type exportPage struct {
+	cols []gocql.ColumnInfo
+	rows [][]output.Value
+}
+
+// copyTo exports a table by scanning ranges of the token ring concurrently
+func copyTo(cks *db.CQLKeyspaceSession, stmt *copyStmt) error {
+	opts := stmt.options
+	if err := opts.check("header", "delimiter", "null", "format", "pagesize", "numprocesses"); err != nil {
+		return err
+	}
+	header, err := opts.boolean("header", false)
+	if err != nil {
+		return err
+	}
+	delimiter, err := opts.char("delimiter", ',')
+	if err != nil {
+		return err
+	}
+	pageSize, err := opts.positive("pagesize", defaultCopyPageSize)
+	if err != nil {
+		return err
+	}
+	workers, err := opts.positive("numprocesses", defaultCopyWorkers())
+	if err != nil {
+		return err
+	}
+	format := strings.ToLower(opts.str("format", "csv"))
+	if format != "csv" && format != "ndjson" {
+		return fmt.Errorf("Invalid value %s for COPY option FORMAT, expected csv or ndjson", format)
+	}
+
+	queries, err := exportQueries(cks, stmt, workers*rangesPerWorker)
+	if err != nil {
+		return err
+	}
+
+	out, report := io.Writer(os.Stdout), os.Stdout
+	if stmt.file == "STDOUT" {
+		report = os.Stderr
+	} else {
+		f, err := os.Create(stmt.file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+
+	var formatter output.Formatter
+	if format == "csv" {
+		formatter = output.NewCSVFormatter(w, output.CSVOptions{Delimiter: delimiter, Header: header, Null: opts.str("null", "")})
+	} else {
+		formatter, _ = output.NewFormatter(format, w, false)
+	}
+
+	progress := newCopyProgress(report)
+	total, err := exportRanges(cks, queries, workers, pageSize, func(page exportPage, first int) error {
+		cols := make([]output.Column, len(page.cols))
+		for i, col := range page.cols {
+			cols[i] = output.Column{Name: col.Name}
+		}
+		if err := formatter.WritePage(cols, page.rows, first); err != nil {
+			return err
+		}
+		progress.update(first + len(page.rows))
+		return nil
+	})
+	if err == nil {
+		err = formatter.Finish(total)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		return err
+	}
+
+	progress.finish(total, "exported to", stmt.file)
+	return nil
+}
+
+// exportQueries builds a SELECT for every range of the token ring, or a
+// single one when the ring of the partitioner can not be split
+func exportQueries(cks *db.CQLKeyspaceSession, stmt *copyStmt, ranges int) ([]string, error) {
+	km, err := cks.KeyspaceMetadata(stmt.keyspace)
+	if err != nil {
+		return nil, err
+	}
+	tm, ok := km.Tables[stmt.table]
+	if !ok {
+		return nil, fmt.Errorf("Table %s not in keyspace %s", stmt.table, stmt.keyspace)
+	}
+
+	cols := "*"
+	if len(stmt.columns) > 0 {
+		quoted := make([]string, len(stmt.columns))
+		for i, col := range stmt.columns {
+			quoted[i] = quoteIdent(col)
+		}
+		cols = strings.Join(quoted, ", ")
+	}
+	selectStmt := fmt.Sprintf("SELECT %s FROM %s", cols, qualifiedName(stmt.keyspace, stmt.table))
+
+	partitioner, err := cks.FetchPartitioner()
+	if err != nil {
+		return nil, err
+	}
+	tokenRanges := db.SplitTokenRing(partitioner, ranges)
+	if tokenRanges == nil {
+		return []string{selectStmt}, nil
+	}
+
+	keys := make([]string, len(tm.PartitionKey))
+	for i, col := range tm.PartitionKey {
+		keys[i] = quoteIdent(col.Name)
+	}
+	token := "token(" + strings.Join(keys, ", ") + ")"
+
+	queries := make([]string, len(tokenRanges))
+	for i, r := range tokenRanges {
+		queries[i] = fmt.Sprintf("%s WHERE %s > %s AND %s <= %s", selectStmt, token, r.Start, token, r.End)
+	}
+	return queries, nil
+}
+
+// exportRanges runs the queries with a pool of workers and hands the pages
+// to write one at a time, first is the number of rows written before. It
+// returns the number of rows read and stops at the first error. The queries
+// follow the consistency, serial consistency and tracing of the shell.
+func exportRanges(cks *db.CQLKeyspaceSession, queries []string, workers int, pageSize int, write func(page exportPage, first int) error) (int, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracer := NewTracer(cks)
+	defer tracer.Close()
+
+	queue := make(chan string)
+	pages := make(chan exportPage, workers)
+	errs := make(chan error, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers && i < len(queries); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cql := range queue {
+				if err := exportRange(ctx, tracer, cql, pageSize, pages); err != nil {
+					errs <- err
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(queue)
+		for _, cql := range queries {
+			select {
+			case queue <- cql:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(pages)
+	}()
+
+	total := 0
+	var writeErr error
+	for page := range pages {
+		// the remaining pages are drained so the workers can stop
+		if writeErr != nil {
+			continue
+		}
+		if writeErr = write(page, total); writeErr != nil {
+			cancel()
+		}
+		total += len(page.rows)
+	}
+
+	if writeErr != nil {
+		return total, writeErr
+	}
+	select {
+	case err := <-errs:
+		return total, err
+	default:
+		return total, nil
+	}
+}
+
+// exportRange reads the rows of a query page by page
+func exportRange(ctx context.Context, tracer *tracer, cql string, pageSize int, pages chan<- exportPage) error {
+	var pageState []byte
+	for {
+		// the page state keeps the driver from fetching the next page by
+		// itself, the range is never held in memory as a whole
+		iter := tracer.Query(cql).WithContext(ctx).PageSize(pageSize).PageState(pageState).Iter()
+		rows, err := readPage(iter, exportValue)
+		if err != nil {
+			_ = iter.Close()
+			return err
+		}
+		pageState = iter.PageState()
+		if err := iter.Close(); err != nil {
+			return err
+		}
+
+		pages <- exportPage{cols: iter.Columns(), rows: rows}
+		if len(pageState) == 0 {
+			return nil
+		}
+	}
+}
+
+// exportValue renders a value like the shell does, but without truncating
+// vectors
+func exportValue(col gocql.ColumnInfo, value interface{}) string {
+	if t, ok := db.ResolveCustomType(col.TypeInfo).(db.VectorType); ok {
+		if elems, ok := value.([]interface{}); ok {
+			parts := make([]string, len(elems))
+			for i, e := range elems {
+				parts[i] = formatValue(t.Elem, e, true)
+			}
+			return "[" + strings.Join(parts, ", ") + "]"
+		}
+	}
+	return formatValue(col.TypeInfo, value, false)
+}
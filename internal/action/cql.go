@@ -2,162 +2,157 @@ package action
 
 import (
 	"fmt"
-	"strings"
+	"os"
+
+	"github.com/gocql/gocql"
 
 	"github.com/npenkov/gcqlsh/internal/output"
 
 	"github.com/npenkov/gcqlsh/internal/db"
+	"github.com/npenkov/gcqlsh/internal/lexer"
 )
 
+// ProcessCommand runs a shell command or sends the statement to the
+// cluster. breakLoop is set when the shell has to exit, continueLoop when
+// the statement holds nothing to run.
 func ProcessCommand(cql string, cks *db.CQLKeyspaceSession) (breakLoop bool, continueLoop bool, errRet error) {
-	breakLoop = false
-	continueLoop = false
-	errRet = nil
-
-	cql = strings.TrimSpace(cql)
-
-	if strings.HasPrefix(cql, "exit") {
-		breakLoop = true
-		return
+	if lexer.Remainder(cql) == "" {
+		return false, true, nil
 	}
 
-	if cql == "" || strings.HasPrefix(cql, "--") {
-		continueLoop = true
-		return
-	}
-
-	if strings.HasPrefix(cql, "use ") || strings.HasPrefix(cql, "USE ") {
-		// Remove leading use keyword and trailing semicolon
-		scriptKeyspace := strings.TrimPrefix(cql, "use ")
-		scriptKeyspace = strings.TrimPrefix(scriptKeyspace, "USE ")
-		scriptKeyspace = strings.TrimSuffix(scriptKeyspace, ";")
-		scriptKeyspace = strings.TrimPrefix(scriptKeyspace, "\"")
-		scriptKeyspace = strings.TrimSuffix(scriptKeyspace, "\"")
-
-		scriptKeyspace = strings.TrimSpace(scriptKeyspace)
-		// Create new session as gocql does not support changing keyspaces in session
-		s, closef, err := db.NewSession(cks.Host, cks.Port, cks.Username, cks.Password, scriptKeyspace)
-		if err == nil {
-			if cks.CloseSessionFunc != nil {
-				cks.CloseSessionFunc()
-			}
-			cks.Session = s
-			cks.ActiveKeyspace = scriptKeyspace
-			cks.CloseSessionFunc = closef
-		}
-		continueLoop = true
-		return
+	name, args := splitCommand(cql)
+	if cmd, ok := commands[name]; ok {
+		return cmd(cks, args)
 	}
+	return false, false, execCQL(cks, cql)
+}
 
-	if strings.HasPrefix(cql, "desc ") || strings.HasPrefix(cql, "DESC ") {
-		errRet = describeCmd(cks, cql)
-		return
-	}
+const (
+	// defaultPageSize is the number of rows shown at once when paging is on
+	defaultPageSize = 100
+	// streamPageSize is the number of rows fetched at once when the output
+	// is not paused between pages
+	streamPageSize = 5000
+)
 
-	if strings.HasPrefix(cql, "tracing ") || strings.HasPrefix(cql, "TRACING ") {
-		errRet = tracingCmd(cks, cql)
-		return
+func execSelectCQL(cks *db.CQLKeyspaceSession, cql string) error {
+	formatter, err := output.NewFormatter(cks.Format, os.Stdout, cks.ExpandEnabled)
+	if err != nil {
+		return err
 	}
-	errRet = execCQL(cks, cql)
-	return
-}
 
-func execSelectCQL(cks *db.CQLKeyspaceSession, cql string) error {
 	tracer := NewTracer(cks)
 	defer tracer.Close()
-	qry := tracer.Query(cql)
-	iter := qry.Iter()
-
-	cntCols := len(iter.Columns())
-	cellWidths := make(map[string]int, cntCols)
-
-	res := make(map[string]interface{}, cntCols)
-	var rows []map[string]string
-	// Print header
-	fmt.Println("")
-
-	rowIdx := 0
-	for iter.MapScan(res) {
-		row := make(map[string]string, cntCols)
-		for colIdx := range iter.Columns() {
-			col := iter.Columns()[colIdx]
-			value := res[col.Name]
-			row[col.Name] = printRowValue(col, value)
-			if len(row[col.Name]) > cellWidths[col.Name] {
-				cellWidths[col.Name] = len(row[col.Name])
-			}
+
+	// machine readable output is never paused
+	pause := cks.PagingEnabled && cks.MorePrompt != nil &&
+		(cks.Format == "" || cks.Format == output.DefaultFormat)
+	pageSize := streamPageSize
+	if pause {
+		output.Paused(formatter)
+		pageSize = cks.PageSize
+		if pageSize <= 0 {
+			pageSize = defaultPageSize
 		}
-		rows = append(rows, row)
-		res = make(map[string]interface{}, cntCols)
-		rowIdx++
-	}
-	if err := iter.Close(); err != nil {
-		fmt.Printf("error executing cql cql=%q err=%v\n", cql, err)
-		return err
 	}
 
-	for colIdx := range iter.Columns() {
-		col := iter.Columns()[colIdx]
-		if len(col.Name) > cellWidths[col.Name] {
-			cellWidths[col.Name] = len(col.Name)
+	total := 0
+	var cols []output.Column
+	var pageState []byte
+	for {
+		// setting the page state, even an empty one, turns off auto paging
+		iter := tracer.Query(cql).PageSize(pageSize).PageState(pageState).Iter()
+		rows, err := readPage(iter, printRowValue)
+		if err != nil {
+			_ = iter.Close()
+			return fmt.Errorf("error decoding row: %w", err)
 		}
-		var color func(a ...interface{}) string
-		if db.IsPartitionKeyColumn(col, cks.Session) {
-			color = output.Red
-		} else if db.IsClusterKeyColumn(col, cks.Session) {
-			color = output.Blue
-		} else {
-			color = output.Magenta
+		pageState = iter.PageState()
+		if err := iter.Close(); err != nil {
+			return err
 		}
 
-		output.PrintColoredColumnVal(cellWidths[col.Name], col.Name, color)
-	}
-	fmt.Printf("\n")
+		if cols == nil {
+			cols = resultColumns(cks, iter.Columns())
+		}
+		if err := formatter.WritePage(cols, rows, total); err != nil {
+			return err
+		}
+		total += len(rows)
 
-	// Print header delimeter
-	for colIdx := range iter.Columns() {
-		col := iter.Columns()[colIdx]
-		//output.PrintColumnVal(cellWidths[col.Name]+2, strings.Repeat("-", cellWidths[col.Name]+2))
-		output.PrintHeaderSeparator(cellWidths[col.Name])
+		if len(pageState) == 0 {
+			break
+		}
+		if pause && len(rows) > 0 && !cks.MorePrompt() {
+			break
+		}
 	}
-	fmt.Printf("\n")
-
-	// Print row data
-	for rowIdx := range rows {
-		row := rows[rowIdx]
-		for colIdx := range iter.Columns() {
-			col := iter.Columns()[colIdx]
-
-			var color func(a ...interface{}) string
-			if db.IsStringColumn(col) {
-				color = output.Yellow
-			} else {
-				color = output.Green
+
+	return formatter.Finish(total)
+}
+
+// readPage decodes the rows of the page fetched by iter, text renders the
+// values for the text based formats. Only the rows of the current page are
+// read, even if the driver would fetch the next one.
+func readPage(iter *gocql.Iter, text func(gocql.ColumnInfo, interface{}) string) ([][]output.Value, error) {
+	cols := iter.Columns()
+	scanner := newRowScanner(cols)
+	n := iter.NumRows()
+	rows := make([][]output.Value, 0, n)
+	for len(rows) < n && iter.Scan(scanner.dest...) {
+		values, err := scanner.values()
+		if err != nil {
+			return nil, err
+		}
+		row := make([]output.Value, len(cols))
+		for colIdx, col := range cols {
+			row[colIdx] = output.Value{
+				Text: text(col, values[colIdx]),
+				JSON: jsonValue(col.TypeInfo, values[colIdx]),
 			}
-			output.PrintColoredColumnVal(cellWidths[col.Name], row[col.Name], color)
 		}
-		fmt.Printf("\n")
-	}
-	if len(rows) == 1 {
-		fmt.Printf("\n (%d row)\n", len(rows))
-	} else {
-		fmt.Printf("\n (%d rows)\n", len(rows))
+		rows = append(rows, row)
 	}
+	return rows, nil
+}
 
-	return nil
+// resultColumns describes the result columns, highlighting the primary key
+func resultColumns(cks *db.CQLKeyspaceSession, cols []gocql.ColumnInfo) []output.Column {
+	columns := make([]output.Column, len(cols))
+	for i, col := range cols {
+		columns[i] = output.Column{Name: col.Name, HeaderColor: output.Magenta, ValueColor: output.Green}
+		if db.IsPartitionKeyColumn(col, cks) {
+			columns[i].HeaderColor = output.Red
+		} else if db.IsClusterKeyColumn(col, cks) {
+			columns[i].HeaderColor = output.Blue
+		}
+		if db.IsStringColumn(col) {
+			columns[i].ValueColor = output.Yellow
+		}
+	}
+	return columns
 }
 
 func execCQL(cks *db.CQLKeyspaceSession, cql string) error {
-	if strings.HasPrefix(cql, "select") || strings.HasPrefix(cql, "SELECT") {
+	name, _ := splitCommand(cql)
+	if name == "select" {
 		return execSelectCQL(cks, cql)
 	} else {
 		tracer := NewTracer(cks)
 		defer tracer.Close()
 		if err := tracer.Query(cql).RetryPolicy(nil).Exec(); err != nil {
-			fmt.Printf("error executing cql cql=%q err=%v\n", cql, err)
 			return err
 		}
 	}
+	if isDDL(name) {
+		cks.InvalidateSchema()
+	}
 
 	return nil
 }
+
+// isDDL reports whether a statement starting with the word name changes the
+// schema
+func isDDL(name string) bool {
+	return name == "create" || name == "alter" || name == "drop"
+}
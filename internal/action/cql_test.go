@@ -1,6 +1,7 @@
 package action
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -83,8 +84,8 @@ func TestProcessCommand_UseKeyspace(t *testing.T) {
 		t.Error("Expected breakLoop to be false for USE command")
 	}
 
-	if !continueLoop {
-		t.Error("Expected continueLoop to be true for USE command")
+	if continueLoop {
+		t.Error("Expected continueLoop to be false for USE command, it runs a statement")
 	}
 
 	if err != nil {
@@ -103,6 +104,36 @@ func TestProcessCommand_UseKeyspace(t *testing.T) {
 	}
 }
 
+func TestProcessCommand_UseKeyspaceKeepsSessions(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+
+	original := testSession.Session
+	if _, _, err := ProcessCommand("USE system;", testSession); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	system := testSession.Session
+	if _, _, err := ProcessCommand("USE test_keyspace;", testSession); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if testSession.Session != original {
+		t.Error("Expected the session of test_keyspace to be used again")
+	}
+	if original.Closed() || system.Closed() {
+		t.Error("Expected the sessions to stay open")
+	}
+
+	_, _, err := ProcessCommand("USE no_such_keyspace;", testSession)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected unknown keyspace error, got: %v", err)
+	}
+	if testSession.Session != original {
+		t.Error("Expected the session to be kept after an error")
+	}
+}
+
 func TestProcessCommand_UseKeyspaceUppercase(t *testing.T) {
 	skipIfDockerUnavailable(t)
 	if testSession == nil {
@@ -116,8 +147,8 @@ func TestProcessCommand_UseKeyspaceUppercase(t *testing.T) {
 		t.Error("Expected breakLoop to be false for USE command")
 	}
 
-	if !continueLoop {
-		t.Error("Expected continueLoop to be true for USE command")
+	if continueLoop {
+		t.Error("Expected continueLoop to be false for USE command, it runs a statement")
 	}
 
 	if err != nil {
@@ -407,3 +438,117 @@ func TestProcessCommand_TracingOff(t *testing.T) {
 		t.Error("Expected tracing to be disabled")
 	}
 }
+
+func TestProcessCommand_DescribeKeyspace(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+
+	breakLoop, continueLoop, err := ProcessCommand("DESC KEYSPACE test_keyspace;", testSession)
+
+	if breakLoop {
+		t.Error("Expected breakLoop to be false for DESC KEYSPACE command")
+	}
+
+	if continueLoop {
+		t.Error("Expected continueLoop to be false for DESC KEYSPACE command")
+	}
+
+	if err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+
+	_, _, err = ProcessCommand("desc keyspace no_such_keyspace", testSession)
+	if err == nil {
+		t.Error("Expected error for unknown keyspace")
+	}
+}
+
+func TestProcessCommand_DescribeSchema(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+
+	for _, cmd := range []string{"desc schema;", "DESC FULL SCHEMA;"} {
+		breakLoop, continueLoop, err := ProcessCommand(cmd, testSession)
+
+		if breakLoop {
+			t.Errorf("Expected breakLoop to be false for %q", cmd)
+		}
+
+		if continueLoop {
+			t.Errorf("Expected continueLoop to be false for %q", cmd)
+		}
+
+		if err != nil {
+			t.Errorf("Expected no error for %q, got: %v", cmd, err)
+		}
+	}
+}
+
+func TestProcessCommand_DescribeTypesAndIndexes(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+
+	setup := []string{
+		"CREATE TYPE IF NOT EXISTS test_address (street text, city text)",
+		"CREATE INDEX IF NOT EXISTS users_email_idx ON users (email)",
+	}
+	for _, cql := range setup {
+		if _, _, err := ProcessCommand(cql, testSession); err != nil {
+			t.Fatalf("Failed to execute %q: %v", cql, err)
+		}
+	}
+
+	commands := []string{
+		"DESC TYPES;",
+		"desc type test_address;",
+		"DESC INDEXES",
+		"desc index test_keyspace.users_email_idx;",
+		"DESC FUNCTIONS",
+		"DESC AGGREGATES",
+		"DESC MATERIALIZED VIEWS",
+	}
+	for _, cmd := range commands {
+		if _, _, err := ProcessCommand(cmd, testSession); err != nil {
+			t.Errorf("Expected no error for %q, got: %v", cmd, err)
+		}
+	}
+
+	if _, _, err := ProcessCommand("desc type no_such_type", testSession); err == nil {
+		t.Error("Expected error for unknown type")
+	}
+}
+
+func TestProcessCommand_CaseInsensitive(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+
+	for _, cmd := range []string{"Select * From users Limit 1;", "  describe tables;", "Use test_keyspace;"} {
+		breakLoop, _, err := ProcessCommand(cmd, testSession)
+		if breakLoop {
+			t.Errorf("Expected breakLoop to be false for %q", cmd)
+		}
+		if err != nil {
+			t.Errorf("Expected no error for %q, got: %v", cmd, err)
+		}
+	}
+
+	breakLoop, _, err := ProcessCommand("QUIT;", testSession)
+	if !breakLoop || err != nil {
+		t.Errorf("Expected QUIT to end the loop, got: %t, %v", breakLoop, err)
+	}
+
+	if _, _, err := ProcessCommand("use no_such_keyspace;", testSession); err == nil {
+		t.Error("Expected error for unknown keyspace, got: nil")
+	}
+	if testSession.ActiveKeyspace != "test_keyspace" {
+		t.Errorf("Expected active keyspace to stay test_keyspace, got: %s", testSession.ActiveKeyspace)
+	}
+}
@@ -0,0 +1,496 @@
+package action
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gocql/gocql"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+var unquotedIdentifier = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// reservedKeywords are the CQL keywords that cannot be used as unquoted identifiers
+var reservedKeywords = map[string]bool{
+	"add": true, "allow": true, "alter": true, "and": true, "apply": true,
+	"asc": true, "authorize": true, "batch": true, "begin": true, "by": true,
+	"columnfamily": true, "create": true, "default": true, "delete": true, "desc": true,
+	"describe": true, "drop": true, "entries": true, "execute": true, "from": true,
+	"full": true, "grant": true, "if": true, "in": true, "index": true,
+	"infinity": true, "insert": true, "into": true, "is": true, "keyspace": true,
+	"limit": true, "materialized": true, "mbean": true, "mbeans": true, "modify": true,
+	"nan": true, "norecursive": true, "not": true, "null": true, "of": true,
+	"on": true, "or": true, "order": true, "primary": true, "rename": true,
+	"replace": true, "revoke": true, "schema": true, "select": true, "set": true,
+	"table": true, "to": true, "token": true, "truncate": true, "unlogged": true,
+	"unset": true, "update": true, "use": true, "using": true, "view": true,
+	"where": true, "with": true,
+}
+
+// skippedTableOptions are system_schema columns that describe the table
+// itself rather than an option of the WITH clause
+var skippedTableOptions = map[string]bool{
+	"keyspace_name": true, "table_name": true, "view_name": true, "id": true,
+	"flags": true, "base_table_id": true, "base_table_name": true,
+	"include_all_columns": true, "where_clause": true,
+}
+
+// quoteIdent returns name as a CQL identifier, quoting it when it would not
+// survive a round trip unquoted
+func quoteIdent(name string) string {
+	if unquotedIdentifier.MatchString(name) && !reservedKeywords[name] {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func qualifiedName(keyspace string, name string) string {
+	return quoteIdent(keyspace) + "." + quoteIdent(name)
+}
+
+// quoteString returns s as a single quoted CQL string literal
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.ContainsAny(s, ".eEN") {
+		s += ".0"
+	}
+	return s
+}
+
+func formatStringMap(m map[string]string, first ...string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(m))
+	for _, k := range first {
+		if v, ok := m[k]; ok {
+			parts = append(parts, fmt.Sprintf("%s: %s", quoteString(k), quoteString(v)))
+		}
+	}
+	for _, k := range keys {
+		if contains(first, k) {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", quoteString(k), quoteString(m[k])))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// optionLiteral renders a value read from system_schema as a CQL literal
+// usable in a WITH clause
+func optionLiteral(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return quoteString(val)
+	case float64:
+		return formatFloat(val)
+	case float32:
+		return formatFloat(float64(val))
+	case bool:
+		return strconv.FormatBool(val)
+	case map[string]string:
+		return formatStringMap(val)
+	case map[string][]byte:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(val))
+		for _, k := range keys {
+			parts = append(parts, fmt.Sprintf("%s: 0x%s", quoteString(k), hex.EncodeToString(val[k])))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case []string:
+		parts := make([]string, 0, len(val))
+		for _, s := range val {
+			parts = append(parts, quoteString(s))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func isEmptyOption(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case map[string]string:
+		return len(val) == 0
+	case map[string][]byte:
+		return len(val) == 0
+	case []string:
+		return len(val) == 0
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func createKeyspaceStmt(km *gocql.KeyspaceMetadata) string {
+	replication := make(map[string]string, len(km.StrategyOptions)+1)
+	for k, v := range km.StrategyOptions {
+		replication[k] = fmt.Sprintf("%v", v)
+	}
+	replication["class"] = km.StrategyClass
+	return fmt.Sprintf("CREATE KEYSPACE %s WITH replication = %s  AND durable_writes = %t;",
+		quoteIdent(km.Name), formatStringMap(replication, "class"), km.DurableWrites)
+}
+
+func createTypeStmt(ut *db.UserType) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "CREATE TYPE %s (\n", qualifiedName(ut.Keyspace, ut.Name))
+	for i := range ut.FieldNames {
+		sep := ","
+		if i == len(ut.FieldNames)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(&sb, "    %s %s%s\n", quoteIdent(ut.FieldNames[i]), ut.FieldTypes[i], sep)
+	}
+	sb.WriteString(");")
+	return sb.String()
+}
+
+func createFunctionStmt(f *db.Function) string {
+	args := make([]string, len(f.ArgumentNames))
+	for i := range f.ArgumentNames {
+		args[i] = quoteIdent(f.ArgumentNames[i]) + " " + f.ArgumentTypes[i]
+	}
+	onNull := "RETURNS NULL ON NULL INPUT"
+	if f.CalledOnNullInput {
+		onNull = "CALLED ON NULL INPUT"
+	}
+	return fmt.Sprintf("CREATE FUNCTION %s(%s)\n    %s\n    RETURNS %s\n    LANGUAGE %s\n    AS $$%s$$;",
+		qualifiedName(f.Keyspace, f.Name), strings.Join(args, ", "), onNull, f.ReturnType, f.Language, f.Body)
+}
+
+func createAggregateStmt(a *db.Aggregate) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "CREATE AGGREGATE %s(%s)\n", qualifiedName(a.Keyspace, a.Name), strings.Join(a.ArgumentTypes, ", "))
+	fmt.Fprintf(&sb, "    SFUNC %s\n", quoteIdent(a.StateFunc))
+	fmt.Fprintf(&sb, "    STYPE %s", a.StateType)
+	if a.FinalFunc != "" {
+		fmt.Fprintf(&sb, "\n    FINALFUNC %s", quoteIdent(a.FinalFunc))
+	}
+	if a.InitCond != "" {
+		fmt.Fprintf(&sb, "\n    INITCOND %s", a.InitCond)
+	}
+	sb.WriteString(";")
+	return sb.String()
+}
+
+// columnDefinitions returns the column definitions of a table in partition
+// key, clustering and regular (alphabetical) order
+func columnDefinitions(tm *gocql.TableMetadata) []*gocql.ColumnMetadata {
+	cols := make([]*gocql.ColumnMetadata, 0, len(tm.Columns))
+	cols = append(cols, tm.PartitionKey...)
+	cols = append(cols, tm.ClusteringColumns...)
+	regular := make([]*gocql.ColumnMetadata, 0, len(tm.Columns))
+	for _, c := range tm.Columns {
+		if c.Kind != gocql.ColumnPartitionKey && c.Kind != gocql.ColumnClusteringKey {
+			regular = append(regular, c)
+		}
+	}
+	sort.Slice(regular, func(i, j int) bool { return regular[i].Name < regular[j].Name })
+	return append(cols, regular...)
+}
+
+func primaryKeyClause(tm *gocql.TableMetadata) string {
+	pk := make([]string, len(tm.PartitionKey))
+	for i, c := range tm.PartitionKey {
+		pk[i] = quoteIdent(c.Name)
+	}
+	key := pk[0]
+	if len(pk) > 1 {
+		key = "(" + strings.Join(pk, ", ") + ")"
+	}
+	for _, c := range tm.ClusteringColumns {
+		key += ", " + quoteIdent(c.Name)
+	}
+	return "PRIMARY KEY (" + key + ")"
+}
+
+// withClause renders the clustering order and all table options
+func withClause(tm *gocql.TableMetadata, options map[string]interface{}) string {
+	clauses := make([]string, 0, len(options)+1)
+	if len(tm.ClusteringColumns) > 0 {
+		order := make([]string, len(tm.ClusteringColumns))
+		for i, c := range tm.ClusteringColumns {
+			dir := "ASC"
+			if c.Order == gocql.DESC {
+				dir = "DESC"
+			}
+			order[i] = quoteIdent(c.Name) + " " + dir
+		}
+		clauses = append(clauses, "CLUSTERING ORDER BY ("+strings.Join(order, ", ")+")")
+	}
+	names := make([]string, 0, len(options))
+	for name := range options {
+		if skippedTableOptions[name] || isEmptyOption(options[name]) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		clauses = append(clauses, fmt.Sprintf("%s = %s", name, optionLiteral(options[name])))
+	}
+	if len(clauses) == 0 {
+		return ";"
+	}
+	return " WITH " + strings.Join(clauses, "\n    AND ") + ";"
+}
+
+func createTableStmt(tm *gocql.TableMetadata, options map[string]interface{}) string {
+	inlineKey := len(tm.PartitionKey) == 1 && len(tm.ClusteringColumns) == 0
+	lines := make([]string, 0, len(tm.Columns)+1)
+	for _, c := range columnDefinitions(tm) {
+		line := "    " + quoteIdent(c.Name) + " " + c.Validator
+		if c.Kind == gocql.ColumnStatic {
+			line += " static"
+		}
+		if inlineKey && c.Kind == gocql.ColumnPartitionKey {
+			line += " PRIMARY KEY"
+		}
+		lines = append(lines, line)
+	}
+	if !inlineKey {
+		lines = append(lines, "    "+primaryKeyClause(tm))
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n)%s",
+		qualifiedName(tm.Keyspace, tm.Name), strings.Join(lines, ",\n"), withClause(tm, options))
+}
+
+func createIndexStmt(idx *db.Index) string {
+	target := idx.Options["target"]
+	table := qualifiedName(idx.Keyspace, idx.Table)
+	if idx.Kind != "CUSTOM" {
+		return fmt.Sprintf("CREATE INDEX %s ON %s (%s);", quoteIdent(idx.Name), table, target)
+	}
+	stmt := fmt.Sprintf("CREATE CUSTOM INDEX %s ON %s (%s) USING %s",
+		quoteIdent(idx.Name), table, target, quoteString(idx.Options["class_name"]))
+	opts := make(map[string]string, len(idx.Options))
+	for k, v := range idx.Options {
+		if k != "target" && k != "class_name" {
+			opts[k] = v
+		}
+	}
+	if len(opts) > 0 {
+		stmt += " WITH OPTIONS = " + formatStringMap(opts)
+	}
+	return stmt + ";"
+}
+
+func createViewStmt(v *db.View, tm *gocql.TableMetadata, options map[string]interface{}) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "CREATE MATERIALIZED VIEW %s AS\n", qualifiedName(v.Keyspace, v.Name))
+	if v.IncludeAllColumns {
+		sb.WriteString("    SELECT *\n")
+	} else {
+		cols := columnDefinitions(tm)
+		names := make([]string, len(cols))
+		for i, c := range cols {
+			names[i] = quoteIdent(c.Name)
+		}
+		fmt.Fprintf(&sb, "    SELECT %s\n", strings.Join(names, ", "))
+	}
+	fmt.Fprintf(&sb, "    FROM %s\n", qualifiedName(v.Keyspace, v.BaseTable))
+	fmt.Fprintf(&sb, "    WHERE %s\n", v.WhereClause)
+	fmt.Fprintf(&sb, "    %s\n", primaryKeyClause(tm))
+	sb.WriteString(withClause(tm, options))
+	return sb.String()
+}
+
+var identifierToken = regexp.MustCompile(`"(?:[^"]|"")+"|[A-Za-z_][A-Za-z0-9_]*`)
+
+// typeReferences returns the names referenced by a CQL type string,
+// e.g. "frozen<map<text, address>>" references frozen, map, text and address
+func typeReferences(cqlType string) []string {
+	tokens := identifierToken.FindAllString(cqlType, -1)
+	for i, t := range tokens {
+		if strings.HasPrefix(t, `"`) {
+			tokens[i] = strings.ReplaceAll(t[1:len(t)-1], `""`, `"`)
+		}
+	}
+	return tokens
+}
+
+// sortTypesByDependency orders user types so that every type is created
+// after the types used by its fields. Types without dependencies between
+// them keep their alphabetical order.
+func sortTypesByDependency(types []*db.UserType) []*db.UserType {
+	byName := make(map[string]*db.UserType, len(types))
+	for _, ut := range types {
+		byName[ut.Name] = ut
+	}
+	sorted := make([]*db.UserType, 0, len(types))
+	visited := make(map[string]bool, len(types))
+	var visit func(ut *db.UserType)
+	visit = func(ut *db.UserType) {
+		if visited[ut.Name] {
+			return
+		}
+		visited[ut.Name] = true
+		for _, ft := range ut.FieldTypes {
+			for _, ref := range typeReferences(ft) {
+				if dep, ok := byName[ref]; ok && dep != ut {
+					visit(dep)
+				}
+			}
+		}
+		sorted = append(sorted, ut)
+	}
+	for _, ut := range types {
+		visit(ut)
+	}
+	return sorted
+}
+
+// tableDDL returns the CREATE TABLE statement of a table followed by the
+// statements for its indexes and materialized views
+func tableDDL(cks *db.CQLKeyspaceSession, km *gocql.KeyspaceMetadata, tableName string,
+	indexes []*db.Index, views []*db.View) ([]string, error) {
+	tm, ok := km.Tables[tableName]
+	if !ok {
+		return nil, fmt.Errorf("Table %s not in schema", tableName)
+	}
+	options, err := cks.FetchTableOptions(km.Name, tableName, false)
+	if err != nil {
+		return nil, err
+	}
+	stmts := []string{createTableStmt(tm, options)}
+	for _, idx := range indexes {
+		if idx.Table == tableName {
+			stmts = append(stmts, createIndexStmt(idx))
+		}
+	}
+	for _, v := range views {
+		if v.BaseTable != tableName {
+			continue
+		}
+		vm, ok := km.Tables[v.Name]
+		if !ok {
+			continue
+		}
+		viewOptions, err := cks.FetchTableOptions(km.Name, v.Name, true)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, createViewStmt(v, vm, viewOptions))
+	}
+	return stmts, nil
+}
+
+// keyspaceDDL returns the statements recreating a keyspace and everything it
+// contains, in an order in which they can be executed
+func keyspaceDDL(cks *db.CQLKeyspaceSession, keyspace string) ([]string, error) {
+	km, err := cks.KeyspaceMetadata(keyspace)
+	if err != nil {
+		return nil, err
+	}
+	types, err := cks.FetchUserTypes(keyspace)
+	if err != nil {
+		return nil, err
+	}
+	functions, err := cks.FetchFunctions(keyspace)
+	if err != nil {
+		return nil, err
+	}
+	aggregates, err := cks.FetchAggregates(keyspace)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := cks.FetchIndexes(keyspace)
+	if err != nil {
+		return nil, err
+	}
+	views, err := cks.FetchViews(keyspace)
+	if err != nil {
+		return nil, err
+	}
+
+	stmts := []string{createKeyspaceStmt(km)}
+	for _, ut := range sortTypesByDependency(types) {
+		stmts = append(stmts, createTypeStmt(ut))
+	}
+	for _, f := range functions {
+		stmts = append(stmts, createFunctionStmt(f))
+	}
+	for _, a := range aggregates {
+		stmts = append(stmts, createAggregateStmt(a))
+	}
+
+	viewNames := make(map[string]bool, len(views))
+	for _, v := range views {
+		viewNames[v.Name] = true
+	}
+	tables := make([]string, 0, len(km.Tables))
+	for name := range km.Tables {
+		if !viewNames[name] {
+			tables = append(tables, name)
+		}
+	}
+	sort.Strings(tables)
+	for _, name := range tables {
+		tableStmts, err := tableDDL(cks, km, name, indexes, views)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, tableStmts...)
+	}
+	return stmts, nil
+}
+
+// schemaDDL returns the statements of all user keyspaces, and with full set
+// also of the system keyspaces. System keyspaces cannot be recreated with CQL
+// so their statements are wrapped in a comment to keep the output runnable.
+func schemaDDL(cks *db.CQLKeyspaceSession, full bool) ([]string, error) {
+	keyspaces, err := cks.FetchKeyspaces()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keyspaces)
+	stmts := make([]string, 0)
+	for _, keyspace := range keyspaces {
+		system := db.IsSystemKeyspace(keyspace)
+		if system && !full {
+			continue
+		}
+		ksStmts, err := keyspaceDDL(cks, keyspace)
+		if err != nil {
+			return nil, err
+		}
+		if system {
+			ksStmts = []string{fmt.Sprintf("/*\nWarning: Keyspace %s is a system keyspace and cannot be recreated with CQL.\nStructure, for reference:\n%s\n*/",
+				keyspace, strings.ReplaceAll(strings.Join(ksStmts, "\n\n"), "*/", "* /"))}
+		}
+		stmts = append(stmts, ksStmts...)
+	}
+	return stmts, nil
+}
+
+func printDDL(stmts []string) {
+	for _, stmt := range stmts {
+		fmt.Printf("\n%s\n", stmt)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,166 @@
+package action
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "users", expected: "users"},
+		{name: "created_at", expected: "created_at"},
+		{name: "CamelCase", expected: `"CamelCase"`},
+		{name: "select", expected: `"select"`},
+		{name: "1st", expected: `"1st"`},
+		{name: `with"quote`, expected: `"with""quote"`},
+	}
+
+	for _, tt := range tests {
+		if got := quoteIdent(tt.name); got != tt.expected {
+			t.Errorf("Expected quoteIdent(%q) to be %s, got: %s", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestSortTypesByDependency(t *testing.T) {
+	types := []*db.UserType{
+		{Name: "address", FieldTypes: []string{"text", "frozen<phone>"}},
+		{Name: "person", FieldTypes: []string{"frozen<map<text, frozen<address>>>"}},
+		{Name: "phone", FieldTypes: []string{"text"}},
+	}
+
+	sorted := sortTypesByDependency(types)
+	names := make([]string, len(sorted))
+	for i, ut := range sorted {
+		names[i] = ut.Name
+	}
+
+	if strings.Join(names, ",") != "phone,address,person" {
+		t.Errorf("Expected types in dependency order phone,address,person, got: %v", names)
+	}
+}
+
+func TestCreateTableStmt(t *testing.T) {
+	pk := &gocql.ColumnMetadata{Name: "pk", Kind: gocql.ColumnPartitionKey, Validator: "int"}
+	ck := &gocql.ColumnMetadata{Name: "ck", Kind: gocql.ColumnClusteringKey, Validator: "timestamp", Order: gocql.DESC}
+	st := &gocql.ColumnMetadata{Name: "owner", Kind: gocql.ColumnStatic, Validator: "text"}
+	val := &gocql.ColumnMetadata{Name: "Value", Kind: gocql.ColumnRegular, Validator: "frozen<list<int>>"}
+	tm := &gocql.TableMetadata{
+		Keyspace:          "ks",
+		Name:              "events",
+		PartitionKey:      []*gocql.ColumnMetadata{pk},
+		ClusteringColumns: []*gocql.ColumnMetadata{ck},
+		Columns:           map[string]*gocql.ColumnMetadata{"pk": pk, "ck": ck, "owner": st, "Value": val},
+	}
+	options := map[string]interface{}{
+		"keyspace_name":          "ks",
+		"table_name":             "events",
+		"gc_grace_seconds":       864000,
+		"bloom_filter_fp_chance": 0.01,
+		"crc_check_chance":       1.0,
+		"comment":                "it's",
+		"caching":                map[string]string{"keys": "ALL", "rows_per_partition": "NONE"},
+		"extensions":             map[string][]byte{},
+	}
+
+	expected := `CREATE TABLE ks.events (
+    pk int,
+    ck timestamp,
+    "Value" frozen<list<int>>,
+    owner text static,
+    PRIMARY KEY (pk, ck)
+) WITH CLUSTERING ORDER BY (ck DESC)
+    AND bloom_filter_fp_chance = 0.01
+    AND caching = {'keys': 'ALL', 'rows_per_partition': 'NONE'}
+    AND comment = 'it''s'
+    AND crc_check_chance = 1.0
+    AND gc_grace_seconds = 864000;`
+
+	if got := createTableStmt(tm, options); got != expected {
+		t.Errorf("Unexpected CREATE TABLE statement:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestCreateTableStmtSingleKey(t *testing.T) {
+	id := &gocql.ColumnMetadata{Name: "id", Kind: gocql.ColumnPartitionKey, Validator: "uuid"}
+	name := &gocql.ColumnMetadata{Name: "name", Kind: gocql.ColumnRegular, Validator: "text"}
+	tm := &gocql.TableMetadata{
+		Keyspace:     "ks",
+		Name:         "users",
+		PartitionKey: []*gocql.ColumnMetadata{id},
+		Columns:      map[string]*gocql.ColumnMetadata{"id": id, "name": name},
+	}
+
+	expected := "CREATE TABLE ks.users (\n    id uuid PRIMARY KEY,\n    name text\n);"
+	if got := createTableStmt(tm, nil); got != expected {
+		t.Errorf("Unexpected CREATE TABLE statement:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestCreateIndexStmt(t *testing.T) {
+	idx := &db.Index{Keyspace: "ks", Table: "users", Name: "users_email_idx", Kind: "COMPOSITES",
+		Options: map[string]string{"target": "email"}}
+	if got := createIndexStmt(idx); got != "CREATE INDEX users_email_idx ON ks.users (email);" {
+		t.Errorf("Unexpected CREATE INDEX statement: %s", got)
+	}
+
+	custom := &db.Index{Keyspace: "ks", Table: "users", Name: "users_name_sai", Kind: "CUSTOM",
+		Options: map[string]string{"target": "name", "class_name": "StorageAttachedIndex", "case_sensitive": "false"}}
+	expected := "CREATE CUSTOM INDEX users_name_sai ON ks.users (name) USING 'StorageAttachedIndex' WITH OPTIONS = {'case_sensitive': 'false'};"
+	if got := createIndexStmt(custom); got != expected {
+		t.Errorf("Unexpected CREATE CUSTOM INDEX statement: %s", got)
+	}
+}
+
+func TestKeyspaceDDL(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+
+	stmts, err := keyspaceDDL(testSession, "test_keyspace")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(stmts) < 3 {
+		t.Fatalf("Expected keyspace and two tables, got: %v", stmts)
+	}
+
+	if !strings.HasPrefix(stmts[0], "CREATE KEYSPACE test_keyspace WITH replication = {'class': ") {
+		t.Errorf("Expected CREATE KEYSPACE first, got: %s", stmts[0])
+	}
+
+	ddl := strings.Join(stmts, "\n")
+	for _, table := range []string{"test_keyspace.products", "test_keyspace.users"} {
+		if !strings.Contains(ddl, "CREATE TABLE "+table+" (") {
+			t.Errorf("Expected CREATE TABLE for %s, got: %s", table, ddl)
+		}
+	}
+}
+
+func TestQualifiedObjectName(t *testing.T) {
+	tests := []struct {
+		input    string
+		keyspace string
+		name     string
+	}{
+		{input: " users;", keyspace: "active", name: "users"},
+		{input: "Users", keyspace: "active", name: "users"},
+		{input: "other.users", keyspace: "other", name: "users"},
+		{input: `"Ks"."My.Table" ;`, keyspace: "Ks", name: "My.Table"},
+	}
+
+	for _, tt := range tests {
+		keyspace, name := qualifiedObjectName(tt.input, "active")
+		if keyspace != tt.keyspace || name != tt.name {
+			t.Errorf("Expected %q to split into %s/%s, got: %s/%s", tt.input, tt.keyspace, tt.name, keyspace, name)
+		}
+	}
+}
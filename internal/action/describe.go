@@ -7,10 +7,8 @@ import (
 	"github.com/npenkov/gcqlsh/internal/db"
 )
 
-func describeCmd(cks *db.CQLKeyspaceSession, cmd string) error {
-	desc := strings.TrimPrefix(strings.TrimPrefix(cmd, "desc "), "DESC ")
-	desc = strings.TrimSpace(desc)
-	if strings.HasPrefix(desc, "keyspaces") || strings.HasPrefix(desc, "KEYSPACES") {
+func describeCmd(cks *db.CQLKeyspaceSession, desc string) error {
+	if _, ok := matchKeywords(desc, "keyspaces"); ok {
 		keyspaces, _ := cks.FetchKeyspaces()
 		for ksi := range keyspaces {
 			fmt.Printf("%s\n", keyspaces[ksi])
@@ -18,12 +16,35 @@ func describeCmd(cks *db.CQLKeyspaceSession, cmd string) error {
 		return nil
 	}
 
-	if strings.HasPrefix(desc, "keyspace") || strings.HasPrefix(desc, "KEYSPACE") {
-		// TODO:
+	if name, ok := matchKeywords(desc, "keyspace"); ok {
+		keyspace := objectName(name)
+		if keyspace == "" {
+			keyspace = cks.ActiveKeyspace
+		}
+		stmts, err := keyspaceDDL(cks, keyspace)
+		if err != nil {
+			return err
+		}
+		printDDL(stmts)
+		return nil
+	}
+
+	if _, ok := matchKeywords(desc, "cluster"); ok {
+		return describeCluster(cks)
+	}
+
+	_, schema := matchKeywords(desc, "schema")
+	_, fullSchema := matchKeywords(desc, "full", "schema")
+	if schema || fullSchema {
+		stmts, err := schemaDDL(cks, fullSchema)
+		if err != nil {
+			return err
+		}
+		printDDL(stmts)
 		return nil
 	}
 
-	if strings.HasPrefix(desc, "tables") || strings.HasPrefix(desc, "TABLES") {
+	if _, ok := matchKeywords(desc, "tables"); ok {
 		tables, _ := cks.FetchTables()
 		for ti := range tables {
 			fmt.Printf("%s\n", tables[ti])
@@ -31,43 +52,202 @@ func describeCmd(cks *db.CQLKeyspaceSession, cmd string) error {
 		return nil
 	}
 
-	if strings.HasPrefix(desc, "table") || strings.HasPrefix(desc, "TABLE") {
-		tableName := strings.TrimPrefix(strings.TrimPrefix(desc, "table"), "TABLE")
-		tableName = strings.TrimSuffix(tableName, ";")
-		tableName = strings.TrimSpace(tableName)
+	if name, ok := matchKeywords(desc, "table"); ok {
+		keyspace, tableName := qualifiedObjectName(name, cks.ActiveKeyspace)
+		km, err := cks.KeyspaceMetadata(keyspace)
+		if err != nil {
+			return err
+		}
+		indexes, err := cks.FetchIndexes(keyspace)
+		if err != nil {
+			return err
+		}
+		views, err := cks.FetchViews(keyspace)
+		if err != nil {
+			return err
+		}
+		stmts, err := tableDDL(cks, km, tableName, indexes, views)
+		if err != nil {
+			return err
+		}
+		printDDL(stmts)
+		return nil
+	}
+
+	if _, ok := matchKeywords(desc, "types"); ok {
+		types, err := cks.FetchUserTypes(cks.ActiveKeyspace)
+		if err != nil {
+			return err
+		}
+		for _, ut := range types {
+			fmt.Printf("%s\n", ut.Name)
+		}
+		return nil
+	}
 
-		columns, _ := cks.FetchColumns(tableName)
-		colWidthName := 0
-		colWidthType := 0
+	if name, ok := matchKeywords(desc, "type"); ok {
+		keyspace, typeName := qualifiedObjectName(name, cks.ActiveKeyspace)
+		types, err := cks.FetchUserTypes(keyspace)
+		if err != nil {
+			return err
+		}
+		for _, ut := range types {
+			if ut.Name == typeName {
+				printDDL([]string{createTypeStmt(ut)})
+				return nil
+			}
+		}
+		return fmt.Errorf("Type %s not in keyspace %s", typeName, keyspace)
+	}
 
-		colsData := make(map[string]string, len(columns))
+	if _, ok := matchKeywords(desc, "functions"); ok {
+		functions, err := cks.FetchFunctions(cks.ActiveKeyspace)
+		if err != nil {
+			return err
+		}
+		for _, f := range functions {
+			fmt.Printf("%s(%s)\n", f.Name, strings.Join(f.ArgumentTypes, ", "))
+		}
+		return nil
+	}
 
-		for _, col := range columns {
-			colsData[col.Name] = fmt.Sprintf("%s", col.Type)
-			if len(col.Name) > colWidthName {
-				colWidthName = len(col.Name)
+	if name, ok := matchKeywords(desc, "function"); ok {
+		keyspace, functionName := qualifiedObjectName(name, cks.ActiveKeyspace)
+		functions, err := cks.FetchFunctions(keyspace)
+		if err != nil {
+			return err
+		}
+		// print all overloads of the function
+		stmts := make([]string, 0)
+		for _, f := range functions {
+			if f.Name == functionName {
+				stmts = append(stmts, createFunctionStmt(f))
 			}
-			if len(col.Name) > colWidthType {
-				colWidthType = len(col.Name)
+		}
+		if len(stmts) == 0 {
+			return fmt.Errorf("Function %s not in keyspace %s", functionName, keyspace)
+		}
+		printDDL(stmts)
+		return nil
+	}
+
+	if _, ok := matchKeywords(desc, "aggregates"); ok {
+		aggregates, err := cks.FetchAggregates(cks.ActiveKeyspace)
+		if err != nil {
+			return err
+		}
+		for _, a := range aggregates {
+			fmt.Printf("%s(%s)\n", a.Name, strings.Join(a.ArgumentTypes, ", "))
+		}
+		return nil
+	}
+
+	if name, ok := matchKeywords(desc, "aggregate"); ok {
+		keyspace, aggregateName := qualifiedObjectName(name, cks.ActiveKeyspace)
+		aggregates, err := cks.FetchAggregates(keyspace)
+		if err != nil {
+			return err
+		}
+		stmts := make([]string, 0)
+		for _, a := range aggregates {
+			if a.Name == aggregateName {
+				stmts = append(stmts, createAggregateStmt(a))
 			}
 		}
-		fmt.Printf(fmt.Sprintf("| %%%ds ", colWidthName), "Name")
-		fmt.Printf(fmt.Sprintf("| %%%ds ", colWidthType), "Type")
-		fmt.Printf("\n")
+		if len(stmts) == 0 {
+			return fmt.Errorf("Aggregate %s not in keyspace %s", aggregateName, keyspace)
+		}
+		printDDL(stmts)
+		return nil
+	}
 
-		// Print header delimeter
-		fmt.Printf(fmt.Sprintf("+%%%ds", colWidthName), strings.Repeat("-", colWidthName+2))
-		fmt.Printf(fmt.Sprintf("+%%%ds", colWidthType), strings.Repeat("-", colWidthType+2))
-		fmt.Printf("\n")
+	if _, ok := matchKeywords(desc, "materialized", "views"); ok {
+		views, err := cks.FetchViews(cks.ActiveKeyspace)
+		if err != nil {
+			return err
+		}
+		for _, v := range views {
+			fmt.Printf("%s\n", v.Name)
+		}
+		return nil
+	}
 
-		for colName, colType := range colsData {
-			fmt.Printf(fmt.Sprintf("| %%%ds ", -colWidthName), colName)
-			fmt.Printf(fmt.Sprintf("| %%%ds ", -colWidthType), colType)
-			fmt.Printf("\n")
+	if name, ok := matchKeywords(desc, "materialized", "view"); ok {
+		keyspace, viewName := qualifiedObjectName(name, cks.ActiveKeyspace)
+		km, err := cks.KeyspaceMetadata(keyspace)
+		if err != nil {
+			return err
+		}
+		views, err := cks.FetchViews(keyspace)
+		if err != nil {
+			return err
+		}
+		for _, v := range views {
+			if vm, ok := km.Tables[v.Name]; ok && v.Name == viewName {
+				options, err := cks.FetchTableOptions(keyspace, viewName, true)
+				if err != nil {
+					return err
+				}
+				printDDL([]string{createViewStmt(v, vm, options)})
+				return nil
+			}
 		}
+		return fmt.Errorf("Materialized view %s not in keyspace %s", viewName, keyspace)
+	}
 
+	if _, ok := matchKeywords(desc, "indexes"); ok {
+		indexes, err := cks.FetchIndexes(cks.ActiveKeyspace)
+		if err != nil {
+			return err
+		}
+		for _, idx := range indexes {
+			fmt.Printf("%s\n", idx.Name)
+		}
 		return nil
 	}
 
-	return nil
+	if name, ok := matchKeywords(desc, "index"); ok {
+		keyspace, indexName := qualifiedObjectName(name, cks.ActiveKeyspace)
+		indexes, err := cks.FetchIndexes(keyspace)
+		if err != nil {
+			return err
+		}
+		for _, idx := range indexes {
+			if idx.Name == indexName {
+				printDDL([]string{createIndexStmt(idx)})
+				return nil
+			}
+		}
+		return fmt.Errorf("Index %s not in keyspace %s", indexName, keyspace)
+	}
+
+	return fmt.Errorf("Improper DESCRIBE command")
+}
+
+// objectName normalizes a schema object name given to a command: the
+// trailing semicolon is dropped, quoted names keep their case and unquoted
+// ones are lowercased as Cassandra does.
+func objectName(s string) string {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), ";"))
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return strings.ToLower(s)
+}
+
+// qualifiedObjectName splits an optionally keyspace qualified name such as
+// ks.table or "Ks"."Table" and falls back to defaultKeyspace when no keyspace
+// is given.
+func qualifiedObjectName(s string, defaultKeyspace string) (string, string) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), ";"))
+	inQuotes := false
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == '.' && !inQuotes:
+			return objectName(s[:i]), objectName(s[i+1:])
+		}
+	}
+	return defaultKeyspace, objectName(s)
 }
@@ -0,0 +1,16 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func TestDescribeUnknownObject(t *testing.T) {
+	cks := &db.CQLKeyspaceSession{}
+	for _, desc := range []string{"tabel users;", "", "keyspacez"} {
+		if err := describeCmd(cks, desc); err == nil {
+			t.Errorf("Expected error for DESCRIBE %q", desc)
+		}
+	}
+}
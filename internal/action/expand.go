@@ -0,0 +1,40 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func expandCmd(cks *db.CQLKeyspaceSession, expand string) error {
+	expand = strings.TrimSpace(strings.TrimSuffix(expand, ";"))
+	if expand == "" {
+		if cks.ExpandEnabled {
+			fmt.Print("Expanded output is currently enabled. Use EXPAND OFF to disable\n")
+		} else {
+			fmt.Print("Expanded output is currently disabled. Use EXPAND ON to enable.\n")
+		}
+		return nil
+	}
+
+	if _, ok := matchKeywords(expand, "on"); ok {
+		if cks.ExpandEnabled {
+			return fmt.Errorf("Expanded output is already enabled. Use EXPAND OFF to disable")
+		}
+		cks.EnableExpand()
+		fmt.Print("Now Expanded output is enabled\n")
+		return nil
+	}
+
+	if _, ok := matchKeywords(expand, "off"); ok {
+		if !cks.ExpandEnabled {
+			return fmt.Errorf("Expanded output is not enabled")
+		}
+		cks.DisableExpand()
+		fmt.Print("Disabled Expanded output.\n")
+		return nil
+	}
+
+	return fmt.Errorf("Improper EXPAND command, use EXPAND ON|OFF")
+}
@@ -0,0 +1,52 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func TestExpandCmd(t *testing.T) {
+	cks := &db.CQLKeyspaceSession{}
+
+	tests := []struct {
+		cmd      string
+		expected bool
+		err      bool
+	}{
+		{cmd: "", expected: false},
+		{cmd: "ON;", expected: true},
+		{cmd: "on", expected: true, err: true},
+		{cmd: "sideways", expected: true, err: true},
+		{cmd: "Off", expected: false},
+		{cmd: "off", expected: false, err: true},
+	}
+
+	for _, tt := range tests {
+		if err := expandCmd(cks, tt.cmd); (err != nil) != tt.err {
+			t.Fatalf("Expected error %t for %q, got: %v", tt.err, tt.cmd, err)
+		}
+		if cks.ExpandEnabled != tt.expected {
+			t.Errorf("Expected expanded output %t after %q, got: %t", tt.expected, tt.cmd, cks.ExpandEnabled)
+		}
+	}
+}
+
+func TestProcessCommand_ExpandedSelect(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+
+	if _, _, err := ProcessCommand("EXPAND ON;", testSession); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	defer testSession.DisableExpand()
+
+	if !testSession.ExpandEnabled {
+		t.Error("Expected expanded output to be enabled")
+	}
+	if _, _, err := ProcessCommand("SELECT * FROM users;", testSession); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+}
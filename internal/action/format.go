@@ -0,0 +1,28 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+	"github.com/npenkov/gcqlsh/internal/output"
+)
+
+func formatCmd(cks *db.CQLKeyspaceSession, format string) error {
+	format = strings.ToLower(objectName(format))
+	if format == "" {
+		current := cks.Format
+		if current == "" {
+			current = output.DefaultFormat
+		}
+		fmt.Printf("Current output format: %s. Available formats: %s\n", current, strings.Join(output.Formats(), ", "))
+		return nil
+	}
+
+	if _, err := output.NewFormatter(format, nil, false); err != nil {
+		return err
+	}
+	cks.Format = format
+	fmt.Printf("Now output format is %s\n", format)
+	return nil
+}
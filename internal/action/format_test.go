@@ -0,0 +1,44 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func TestFormatCmd(t *testing.T) {
+	cks := &db.CQLKeyspaceSession{}
+
+	if err := formatCmd(cks, ""); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := formatCmd(cks, "JSON;"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if cks.Format != "json" {
+		t.Errorf("Expected format json, got: %s", cks.Format)
+	}
+	if err := formatCmd(cks, "xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+	if cks.Format != "json" {
+		t.Errorf("Expected format to stay json, got: %s", cks.Format)
+	}
+}
+
+func TestProcessCommand_FormattedSelect(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+	defer func() { testSession.Format = "" }()
+
+	for _, format := range []string{"csv", "tsv", "json", "ndjson", "markdown", "table"} {
+		if _, _, err := ProcessCommand("FORMAT "+format+";", testSession); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, _, err := ProcessCommand("SELECT * FROM users;", testSession); err != nil {
+			t.Errorf("Expected no error for %s output, got: %v", format, err)
+		}
+	}
+}
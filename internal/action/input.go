@@ -0,0 +1,537 @@
+package action
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+	"github.com/npenkov/gcqlsh/internal/lexer"
+	"github.com/npenkov/gcqlsh/internal/output"
+)
+
+// timestampLayouts are the accepted timestamp formats, the first one is the
+// format of the shell output
+var timestampLayouts = []string{
+	timestampLayout,
+	"2006-01-02 15:04:05.999999999-0700",
+	"2006-01-02 15:04:05-0700",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999-0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	dateLayout,
+}
+
+// parseField converts a field of an imported row to a value gocql can
+// marshal as the given type. Text values are taken as they are, values of
+// collections, tuples, user types and vectors are CQL literals as the shell
+// prints them.
+func parseField(info gocql.TypeInfo, s string) (interface{}, error) {
+	info = db.ResolveCustomType(info)
+	switch info.(type) {
+	case db.VectorType, gocql.CollectionType, gocql.TupleTypeInfo, gocql.UDTTypeInfo:
+		p := &literalParser{tokens: lexer.Tokenize(s)}
+		v, err := p.value(info)
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := p.peek(); ok {
+			return nil, fmt.Errorf("unexpected %s after %s value", t.Text, typeName(info))
+		}
+		return v, nil
+	}
+	return parseScalar(info, s)
+}
+
+// parseScalar converts the text of a value that is neither a collection,
+// tuple, user type nor vector
+func parseScalar(info gocql.TypeInfo, s string) (interface{}, error) {
+	switch info.Type() {
+	case gocql.TypeAscii, gocql.TypeText, gocql.TypeVarchar:
+		return s, nil
+	}
+
+	s = strings.TrimSpace(s)
+	invalid := func() (interface{}, error) {
+		return nil, fmt.Errorf("invalid %s value %q", typeName(info), s)
+	}
+
+	switch info.Type() {
+	case gocql.TypeBoolean:
+		switch strings.ToLower(s) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	case gocql.TypeTinyInt, gocql.TypeSmallInt, gocql.TypeInt, gocql.TypeBigInt, gocql.TypeCounter:
+		bits := map[gocql.Type]int{gocql.TypeTinyInt: 8, gocql.TypeSmallInt: 16, gocql.TypeInt: 32}[info.Type()]
+		if bits == 0 {
+			bits = 64
+		}
+		n, err := strconv.ParseInt(s, 10, bits)
+		if err != nil {
+			return invalid()
+		}
+		switch bits {
+		case 8:
+			return int8(n), nil
+		case 16:
+			return int16(n), nil
+		case 32:
+			return int32(n), nil
+		}
+		return n, nil
+	case gocql.TypeVarint:
+		if n, ok := new(big.Int).SetString(s, 10); ok {
+			return n, nil
+		}
+	case gocql.TypeFloat:
+		if f, err := strconv.ParseFloat(s, 32); err == nil {
+			return float32(f), nil
+		}
+	case gocql.TypeDouble:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, nil
+		}
+	case gocql.TypeDecimal:
+		if d, ok := new(inf.Dec).SetString(s); ok {
+			return d, nil
+		}
+	case gocql.TypeUUID, gocql.TypeTimeUUID:
+		if u, err := gocql.ParseUUID(s); err == nil {
+			return u, nil
+		}
+	case gocql.TypeInet:
+		if ip := net.ParseIP(s); ip != nil {
+			return ip, nil
+		}
+	case gocql.TypeBlob:
+		if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+			if b, err := hex.DecodeString(s[2:]); err == nil {
+				return b, nil
+			}
+		}
+	case gocql.TypeTimestamp:
+		if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.UnixMilli(ms), nil
+		}
+		for _, layout := range timestampLayouts {
+			if ts, err := time.ParseInLocation(layout, s, output.Location); err == nil {
+				return ts, nil
+			}
+		}
+	case gocql.TypeDate:
+		if ts, err := time.ParseInLocation(dateLayout, s, time.UTC); err == nil {
+			return ts, nil
+		}
+	case gocql.TypeTime:
+		if d, ok := parseTimeOfDay(s); ok {
+			return d, nil
+		}
+	case gocql.TypeDuration:
+		if d, ok := parseDuration(s); ok {
+			return d, nil
+		}
+	default:
+		return nil, fmt.Errorf("importing %s values is not supported", typeName(info))
+	}
+	return invalid()
+}
+
+// parseTimeOfDay parses hh:mm:ss with an optional fraction of up to nine
+// digits
+func parseTimeOfDay(s string) (time.Duration, bool) {
+	clock, frac, _ := strings.Cut(s, ".")
+	parts := strings.Split(clock, ":")
+	if len(parts) != 3 || len(frac) > 9 {
+		return 0, false
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 || i > 0 && n > 59 || i == 0 && n > 23 {
+			return 0, false
+		}
+		d += time.Duration(n) * unit
+	}
+	if frac != "" {
+		n, err := strconv.Atoi(frac + strings.Repeat("0", 9-len(frac)))
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		d += time.Duration(n)
+	}
+	return d, true
+}
+
+// durationUnits are the units of duration literals like 1y2mo3d1h30m
+var durationUnits = []struct {
+	unit   string
+	months int64
+	days   int64
+	nanos  int64
+}{
+	// longer names first, so that mo and ms are not taken for m
+	{unit: "mo", months: 1},
+	{unit: "ms", nanos: int64(time.Millisecond)},
+	{unit: "us", nanos: int64(time.Microsecond)},
+	{unit: "µs", nanos: int64(time.Microsecond)},
+	{unit: "ns", nanos: 1},
+	{unit: "y", months: 12},
+	{unit: "w", days: 7},
+	{unit: "d", days: 1},
+	{unit: "h", nanos: int64(time.Hour)},
+	{unit: "m", nanos: int64(time.Minute)},
+	{unit: "s", nanos: int64(time.Second)},
+}
+
+// parseDuration parses the duration literals Cassandra prints
+func parseDuration(s string) (gocql.Duration, bool) {
+	var d gocql.Duration
+	negative := strings.HasPrefix(s, "-")
+	s = strings.ToLower(strings.TrimPrefix(s, "-"))
+	if s == "" {
+		return d, false
+	}
+	var months, days, nanos int64
+	for s != "" {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return d, false
+		}
+		n, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil {
+			return d, false
+		}
+		s = s[i:]
+		matched := false
+		for _, u := range durationUnits {
+			if strings.HasPrefix(s, u.unit) {
+				months, days, nanos = months+n*u.months, days+n*u.days, nanos+n*u.nanos
+				s, matched = s[len(u.unit):], true
+				break
+			}
+		}
+		if !matched {
+			return d, false
+		}
+	}
+	if negative {
+		months, days, nanos = -months, -days, -nanos
+	}
+	return gocql.Duration{Months: int32(months), Days: int32(days), Nanoseconds: nanos}, true
+}
+
+// literalParser parses the CQL literals of collections, tuples, user types
+// and vectors. The tokens include whitespace, which ends numbers and other
+// unquoted values.
+type literalParser struct {
+	tokens []lexer.Token
+	pos    int
+}
+
+// peek returns the next significant token
+func (p *literalParser) peek() (lexer.Token, bool) {
+	for p.pos < len(p.tokens) && !p.tokens[p.pos].Significant() {
+		p.pos++
+	}
+	if p.pos >= len(p.tokens) {
+		return lexer.Token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *literalParser) symbol(s string) bool {
+	if t, ok := p.peek(); ok && t.Kind == lexer.Symbol && t.Text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *literalParser) expect(s string) error {
+	if p.symbol(s) {
+		return nil
+	}
+	if t, ok := p.peek(); ok {
+		return fmt.Errorf("expected %s at %s", s, t.Text)
+	}
+	return fmt.Errorf("expected %s at end of value", s)
+}
+
+// list parses the comma separated items between open and close
+func (p *literalParser) list(open string, close string, item func() error) error {
+	if err := p.expect(open); err != nil {
+		return err
+	}
+	if p.symbol(close) {
+		return nil
+	}
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		if p.symbol(close) {
+			return nil
+		}
+		if err := p.expect(","); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *literalParser) value(info gocql.TypeInfo) (interface{}, error) {
+	if t, ok := p.peek(); ok && t.Is("null") {
+		p.pos++
+		return nil, nil
+	}
+
+	info = db.ResolveCustomType(info)
+	switch t := info.(type) {
+	case db.VectorType:
+		vector := vectorValue{elem: t.Elem}
+		err := p.list("[", "]", func() error {
+			v, err := p.value(t.Elem)
+			if v == nil && err == nil {
+				err = fmt.Errorf("vector elements can not be null")
+			}
+			vector.values = append(vector.values, v)
+			return err
+		})
+		if err == nil && len(vector.values) != t.Dimensions {
+			err = fmt.Errorf("expected %d vector elements, got %d", t.Dimensions, len(vector.values))
+		}
+		return vector, err
+	case gocql.CollectionType:
+		if t.Type() == gocql.TypeMap {
+			if !hashable(t.Key) {
+				return nil, fmt.Errorf("importing maps with %s keys is not supported", typeName(t.Key))
+			}
+			m := make(map[interface{}]interface{})
+			err := p.list("{", "}", func() error {
+				k, err := p.value(t.Key)
+				if err != nil {
+					return err
+				}
+				if err := p.expect(":"); err != nil {
+					return err
+				}
+				m[k], err = p.value(t.Elem)
+				return err
+			})
+			return m, err
+		}
+		open, close := "[", "]"
+		if t.Type() == gocql.TypeSet {
+			open, close = "{", "}"
+		}
+		elems := make([]interface{}, 0)
+		err := p.list(open, close, func() error {
+			v, err := p.value(t.Elem)
+			elems = append(elems, v)
+			return err
+		})
+		return elems, err
+	case gocql.TupleTypeInfo:
+		elems := make([]interface{}, 0, len(t.Elems))
+		err := p.list("(", ")", func() error {
+			if len(elems) == len(t.Elems) {
+				return fmt.Errorf("expected %d tuple elements", len(t.Elems))
+			}
+			v, err := p.value(t.Elems[len(elems)])
+			elems = append(elems, v)
+			return err
+		})
+		for len(elems) < len(t.Elems) {
+			elems = append(elems, nil)
+		}
+		return elems, err
+	case gocql.UDTTypeInfo:
+		fields := make(map[string]interface{})
+		err := p.list("{", "}", func() error {
+			name, ok := p.peek()
+			if !ok || name.Kind != lexer.Word && name.Kind != lexer.QuotedIdentifier {
+				return fmt.Errorf("expected field name of %s", t.Name)
+			}
+			p.pos++
+			field := objectName(name.Text)
+			for _, e := range t.Elements {
+				if e.Name == field {
+					if err := p.expect(":"); err != nil {
+						return err
+					}
+					v, err := p.value(e.Type)
+					fields[field] = v
+					return err
+				}
+			}
+			return fmt.Errorf("unknown field %s of %s", field, t.Name)
+		})
+		return fields, err
+	}
+
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("expected %s value at end of value", typeName(info))
+	}
+	if t.Kind == lexer.String {
+		p.pos++
+		return parseScalar(info, unquoteString(t.Text))
+	}
+	// numbers, uuids and blobs are taken up to the next separator
+	var sb strings.Builder
+	for ; p.pos < len(p.tokens); p.pos++ {
+		t := p.tokens[p.pos]
+		if !t.Significant() || t.Kind == lexer.String || t.Kind == lexer.Symbol && strings.Contains(",:[]{}()", t.Text) {
+			break
+		}
+		sb.WriteString(t.Text)
+	}
+	if sb.Len() == 0 {
+		return nil, fmt.Errorf("expected %s value at %s", typeName(info), t.Text)
+	}
+	return parseScalar(info, sb.String())
+}
+
+// hashable reports whether values of the type can be Go map keys
+func hashable(info gocql.TypeInfo) bool {
+	switch db.ResolveCustomType(info).(type) {
+	case db.VectorType, gocql.CollectionType, gocql.TupleTypeInfo, gocql.UDTTypeInfo:
+		return false
+	}
+	return info.Type() != gocql.TypeBlob && info.Type() != gocql.TypeInet
+}
+
+// jsonField converts a value of an imported JSON object, decoded with
+// json.Decoder.UseNumber, to a value gocql can marshal as the given type
+func jsonField(info gocql.TypeInfo, value interface{}) (interface{}, error) {
+	info = db.ResolveCustomType(info)
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return parseField(info, v)
+	case json.Number:
+		return parseScalar(info, v.String())
+	case bool:
+		if info.Type() != gocql.TypeBoolean {
+			return nil, fmt.Errorf("invalid %s value %t", typeName(info), v)
+		}
+		return v, nil
+	case []interface{}:
+		switch t := info.(type) {
+		case db.VectorType:
+			if len(v) != t.Dimensions {
+				return nil, fmt.Errorf("expected %d vector elements, got %d", t.Dimensions, len(v))
+			}
+			vector := vectorValue{elem: t.Elem, values: make([]interface{}, len(v))}
+			for i, e := range v {
+				var err error
+				if vector.values[i], err = jsonField(t.Elem, e); err != nil {
+					return nil, err
+				}
+				if vector.values[i] == nil {
+					return nil, fmt.Errorf("vector elements can not be null")
+				}
+			}
+			return vector, nil
+		case gocql.CollectionType:
+			if t.Type() != gocql.TypeMap {
+				return jsonElems(v, func(int) gocql.TypeInfo { return t.Elem })
+			}
+		case gocql.TupleTypeInfo:
+			if len(v) != len(t.Elems) {
+				return nil, fmt.Errorf("expected %d tuple elements, got %d", len(t.Elems), len(v))
+			}
+			return jsonElems(v, func(i int) gocql.TypeInfo { return t.Elems[i] })
+		}
+	case map[string]interface{}:
+		switch t := info.(type) {
+		case gocql.CollectionType:
+			if t.Type() == gocql.TypeMap && hashable(t.Key) {
+				m := make(map[interface{}]interface{}, len(v))
+				for key, e := range v {
+					k, err := parseField(t.Key, key)
+					if err != nil {
+						return nil, err
+					}
+					if m[k], err = jsonField(t.Elem, e); err != nil {
+						return nil, err
+					}
+				}
+				return m, nil
+			}
+		case gocql.UDTTypeInfo:
+			fields := make(map[string]interface{}, len(v))
+			for _, e := range t.Elements {
+				if f, ok := v[e.Name]; ok {
+					var err error
+					if fields[e.Name], err = jsonField(e.Type, f); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if len(fields) != len(v) {
+				return nil, fmt.Errorf("unknown fields of %s", t.Name)
+			}
+			return fields, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s value %v", typeName(info), value)
+}
+
+func jsonElems(elems []interface{}, elemType func(i int) gocql.TypeInfo) ([]interface{}, error) {
+	values := make([]interface{}, len(elems))
+	for i, e := range elems {
+		var err error
+		if values[i], err = jsonField(elemType(i), e); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// vectorValue marshals a vector, which gocql does not know. Fixed length
+// elements are concatenated, others are prefixed with their length.
+type vectorValue struct {
+	elem   gocql.TypeInfo
+	values []interface{}
+}
+
+func (v vectorValue) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	var buf []byte
+	for _, e := range v.values {
+		data, err := gocql.Marshal(v.elem, e)
+		if err != nil {
+			return nil, err
+		}
+		if db.FixedLength(v.elem) == 0 {
+			buf = binary.AppendUvarint(buf, uint64(len(data)))
+		}
+		buf = append(buf, data...)
+	}
+	return buf, nil
+}
+
+// typeName names a type for error messages
+func typeName(info gocql.TypeInfo) string {
+	if t, ok := info.(db.VectorType); ok {
+		return fmt.Sprintf("vector<%s, %d>", typeName(t.Elem), t.Dimensions)
+	}
+	return fmt.Sprintf("%v", info)
+}
@@ -0,0 +1,151 @@
+package action
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+// roundTrip marshals a parsed value and renders it like the shell does
+func roundTrip(t *testing.T, info gocql.TypeInfo, v interface{}) string {
+	t.Helper()
+	data, err := gocql.Marshal(info, v)
+	if err != nil {
+		t.Fatalf("Failed to marshal %v: %v", v, err)
+	}
+	decoded, err := decodeValue(info, data)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	return formatValue(info, decoded, false)
+}
+
+func TestParseField(t *testing.T) {
+	text := nativeType(gocql.TypeText)
+	intType := nativeType(gocql.TypeInt)
+	vector := db.ResolveCustomType(gocql.NewNativeType(4, gocql.TypeCustom,
+		"org.apache.cassandra.db.marshal.VectorType(org.apache.cassandra.db.marshal.FloatType,3)"))
+
+	tests := []struct {
+		name     string
+		info     gocql.TypeInfo
+		input    string
+		expected string
+	}{
+		{name: "text", info: text, input: " it's, ok ", expected: " it's, ok "},
+		{name: "int", info: intType, input: " -42 ", expected: "-42"},
+		{name: "bigint", info: nativeType(gocql.TypeBigInt), input: "9223372036854775807", expected: "9223372036854775807"},
+		{name: "boolean", info: nativeType(gocql.TypeBoolean), input: "True", expected: "True"},
+		{name: "double", info: nativeType(gocql.TypeDouble), input: "19.99", expected: "19.99"},
+		{name: "decimal", info: nativeType(gocql.TypeDecimal), input: "12345.6789", expected: "12345.6789"},
+		{name: "varint", info: nativeType(gocql.TypeVarint), input: "1180591620717411303424", expected: "1180591620717411303424"},
+		{name: "blob", info: nativeType(gocql.TypeBlob), input: "0xcafe", expected: "0xcafe"},
+		{name: "uuid", info: nativeType(gocql.TypeUUID), input: "123e4567-e89b-12d3-a456-426614174000", expected: "123e4567-e89b-12d3-a456-426614174000"},
+		{name: "inet", info: nativeType(gocql.TypeInet), input: "10.0.0.1", expected: "10.0.0.1"},
+		{name: "timestamp", info: nativeType(gocql.TypeTimestamp), input: "2024-03-05 14:07:09.123000+0000", expected: "2024-03-05 14:07:09.123000+0000"},
+		{name: "timestamp RFC 3339", info: nativeType(gocql.TypeTimestamp), input: "2024-03-05T16:07:09+02:00", expected: "2024-03-05 14:07:09.000000+0000"},
+		{name: "date", info: nativeType(gocql.TypeDate), input: "2024-03-05", expected: "2024-03-05"},
+		{name: "time", info: nativeType(gocql.TypeTime), input: "13:00:05.5", expected: "13:00:05.500000000"},
+		{name: "duration", info: nativeType(gocql.TypeDuration), input: "1y2mo3d1h30m5ms", expected: "1y2mo3d1h30m5ms"},
+		{name: "list of text", info: collectionType(gocql.TypeList, nil, text), input: "['a', 'b''c']", expected: "['a', 'b''c']"},
+		{name: "set of int", info: collectionType(gocql.TypeSet, nil, intType), input: "{1, -2}", expected: "{1, -2}"},
+		{name: "map of int to text", info: collectionType(gocql.TypeMap, intType, text), input: "{9: 'y'}", expected: "{9: 'y'}"},
+		{
+			name: "tuple with null element",
+			info: gocql.TupleTypeInfo{NativeType: gocql.NewNativeType(4, gocql.TypeTuple, ""),
+				Elems: []gocql.TypeInfo{intType, text}},
+			input:    "(1, null)",
+			expected: "(1, null)",
+		},
+		{
+			name: "udt",
+			info: gocql.UDTTypeInfo{NativeType: gocql.NewNativeType(4, gocql.TypeUDT, ""), Elements: []gocql.UDTField{
+				{Name: "street", Type: text},
+				{Name: "Zip", Type: intType},
+			}},
+			input:    `{street: 'Main', "Zip": 1000}`,
+			expected: `{street: 'Main', "Zip": 1000}`,
+		},
+		{name: "vector", info: vector, input: "[0.5, -1, 2e3]", expected: "[0.5, -1, 2000]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := parseField(tt.info, tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if got := roundTrip(t, tt.info, v); got != tt.expected {
+				t.Errorf("Expected %s, got: %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseFieldErrors(t *testing.T) {
+	intType := nativeType(gocql.TypeInt)
+	vector := db.ResolveCustomType(gocql.NewNativeType(4, gocql.TypeCustom,
+		"org.apache.cassandra.db.marshal.VectorType(org.apache.cassandra.db.marshal.FloatType,3)"))
+
+	tests := []struct {
+		info  gocql.TypeInfo
+		input string
+	}{
+		{info: intType, input: "1.5"},
+		{info: nativeType(gocql.TypeTinyInt), input: "300"},
+		{info: nativeType(gocql.TypeBoolean), input: "maybe"},
+		{info: nativeType(gocql.TypeTimestamp), input: "yesterday"},
+		{info: nativeType(gocql.TypeDuration), input: "1x"},
+		{info: collectionType(gocql.TypeList, nil, intType), input: "[1, 2"},
+		{info: collectionType(gocql.TypeList, nil, intType), input: "[1 2]"},
+		{info: collectionType(gocql.TypeList, nil, intType), input: "[1] extra"},
+		{info: vector, input: "[1, 2]"},
+	}
+
+	for _, tt := range tests {
+		if _, err := parseField(tt.info, tt.input); err == nil {
+			t.Errorf("Expected an error for %q", tt.input)
+		}
+	}
+}
+
+func TestJSONField(t *testing.T) {
+	text := nativeType(gocql.TypeText)
+	intType := nativeType(gocql.TypeInt)
+
+	tests := []struct {
+		name     string
+		info     gocql.TypeInfo
+		input    string
+		expected string
+	}{
+		{name: "int", info: intType, input: "42", expected: "42"},
+		{name: "text", info: text, input: `"a \"b\""`, expected: `a "b"`},
+		{name: "boolean", info: nativeType(gocql.TypeBoolean), input: "false", expected: "False"},
+		{name: "timestamp", info: nativeType(gocql.TypeTimestamp), input: `"2024-03-05 14:07:09.123000+0000"`, expected: "2024-03-05 14:07:09.123000+0000"},
+		{name: "list of int", info: collectionType(gocql.TypeList, nil, intType), input: "[1, 2]", expected: "[1, 2]"},
+		{name: "map of int to text", info: collectionType(gocql.TypeMap, intType, text), input: `{"9": "y"}`, expected: "{9: 'y'}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := json.NewDecoder(strings.NewReader(tt.input))
+			dec.UseNumber()
+			var raw interface{}
+			if err := dec.Decode(&raw); err != nil {
+				t.Fatalf("Failed to decode %s: %v", tt.input, err)
+			}
+			v, err := jsonField(tt.info, raw)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if got := roundTrip(t, tt.info, v); got != tt.expected {
+				t.Errorf("Expected %s, got: %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package action
 
 import (
+	"sort"
 	"strings"
 
+	"github.com/npenkov/gcqlsh/internal/complete"
 	"github.com/npenkov/gcqlsh/internal/db"
 )
 
@@ -33,3 +35,104 @@ func ListColumns(cks *db.CQLKeyspaceSession, prefix string) func(string) []strin
 		return cols
 	}
 }
+
+// CompletionSource looks up the schema names the completion of the
+// interactive shell suggests
+func CompletionSource(cks *db.CQLKeyspaceSession) complete.Source {
+	return completionSource{cks}
+}
+
+type completionSource struct {
+	cks *db.CQLKeyspaceSession
+}
+
+func (s completionSource) ActiveKeyspace() string {
+	return s.cks.ActiveKeyspace
+}
+
+func (s completionSource) Keyspaces() []string {
+	keyspaces, _ := s.cks.FetchKeyspaces()
+	sort.Strings(keyspaces)
+	return keyspaces
+}
+
+func (s completionSource) Tables(keyspace string) []string {
+	tables, _ := s.cks.FetchKeyspaceTables(keyspace)
+	sort.Strings(tables)
+	return tables
+}
+
+func (s completionSource) Columns(keyspace string, table string) []string {
+	schema, err := s.cks.KeyspaceMetadata(keyspace)
+	if err != nil {
+		return nil
+	}
+	tm, ok := schema.Tables[table]
+	if !ok {
+		return nil
+	}
+	cols := columnDefinitions(tm)
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.Name
+	}
+	return names
+}
+
+func (s completionSource) Types(keyspace string) []string {
+	types, _ := s.cks.FetchUserTypes(keyspace)
+	names := make([]string, 0, len(types))
+	for _, ut := range types {
+		names = append(names, ut.Name)
+	}
+	return names
+}
+
+func (s completionSource) Functions(keyspace string) []string {
+	functions, _ := s.cks.FetchFunctions(keyspace)
+	names := make([]string, 0, len(functions))
+	for _, f := range functions {
+		names = append(names, f.Name)
+	}
+	return uniqueNames(names)
+}
+
+func (s completionSource) Aggregates(keyspace string) []string {
+	aggregates, _ := s.cks.FetchAggregates(keyspace)
+	names := make([]string, 0, len(aggregates))
+	for _, a := range aggregates {
+		names = append(names, a.Name)
+	}
+	return uniqueNames(names)
+}
+
+func (s completionSource) Views(keyspace string) []string {
+	views, _ := s.cks.FetchViews(keyspace)
+	names := make([]string, 0, len(views))
+	for _, v := range views {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+func (s completionSource) Indexes(keyspace string) []string {
+	indexes, _ := s.cks.FetchIndexes(keyspace)
+	names := make([]string, 0, len(indexes))
+	for _, idx := range indexes {
+		names = append(names, idx.Name)
+	}
+	return names
+}
+
+// uniqueNames sorts names and drops the duplicates, overloaded functions
+// share their name
+func uniqueNames(names []string) []string {
+	sort.Strings(names)
+	unique := names[:0]
+	for _, n := range names {
+		if len(unique) == 0 || unique[len(unique)-1] != n {
+			unique = append(unique, n)
+		}
+	}
+	return unique
+}
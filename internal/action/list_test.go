@@ -1,6 +1,7 @@
 package action
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -148,3 +149,11 @@ func TestListColumnsInvalidTable(t *testing.T) {
 		t.Error("Expected no columns for non-existent table")
 	}
 }
+
+func TestUniqueNames(t *testing.T) {
+	got := uniqueNames([]string{"fahrenheit", "celsius", "fahrenheit", "average"})
+	expected := []string{"average", "celsius", "fahrenheit"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got: %v", expected, got)
+	}
+}
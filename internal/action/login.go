@@ -0,0 +1,87 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+	"github.com/npenkov/gcqlsh/internal/lexer"
+)
+
+// parseLogin parses the arguments of LOGIN user ['password'], password is
+// empty when it is not given
+func parseLogin(args string) (user string, password string, err error) {
+	tokens := make([]lexer.Token, 0)
+	for _, t := range lexer.Tokenize(args) {
+		if t.Significant() && t.Text != ";" {
+			tokens = append(tokens, t)
+		}
+	}
+	values := make([]string, len(tokens))
+	for i, t := range tokens {
+		switch {
+		case t.Kind == lexer.String && t.Complete:
+			values[i] = unquoteString(t.Text)
+		case t.Kind == lexer.Word:
+			values[i] = t.Text
+		case t.Kind == lexer.QuotedIdentifier && t.Complete && i == 0:
+			values[i] = objectName(t.Text)
+		default:
+			return "", "", fmt.Errorf("Improper LOGIN command, unexpected %s", t.Text)
+		}
+	}
+	switch len(values) {
+	case 1:
+		return values[0], "", nil
+	case 2:
+		return values[0], values[1], nil
+	}
+	return "", "", errors.New("Improper LOGIN command, expected LOGIN user ['password']")
+}
+
+// loginCmd connects again as another user, the current session is kept
+// when that fails
+func loginCmd(cks *db.CQLKeyspaceSession, args string) error {
+	user, password, err := parseLogin(args)
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		if cks.PasswordPrompt == nil {
+			return errors.New("LOGIN without a password needs an interactive session")
+		}
+		if password, err = cks.PasswordPrompt("Password: "); err != nil {
+			return err
+		}
+	}
+
+	s, closef, _, err := db.NewSession(cks.Host, cks.Port, user, password, cks.ActiveKeyspace, cks.ProtoVersion, cks.SSL, cks.Routing)
+	if err != nil {
+		return err
+	}
+	cks.ReplaceSession(s, closef)
+	cks.Username, cks.Password = user, password
+	fmt.Printf("Logged in as %s\n", user)
+	return nil
+}
+
+var plainUser = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// HistoryEntry returns how a statement is kept in the history file, on a
+// single line. LOGIN is kept without its password and is dropped, returning
+// an empty string, when it can not be parsed.
+func HistoryEntry(cmd string) string {
+	if name, args := splitCommand(cmd); name == "login" {
+		user, _, err := parseLogin(args)
+		if err != nil {
+			return ""
+		}
+		if !plainUser.MatchString(user) {
+			user = "'" + strings.ReplaceAll(user, "'", "''") + "'"
+		}
+		return "LOGIN " + user + ";"
+	}
+	return strings.ReplaceAll(cmd, "\n", " ")
+}
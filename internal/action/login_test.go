@@ -0,0 +1,64 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func TestParseLogin(t *testing.T) {
+	tests := []struct {
+		args     string
+		user     string
+		password string
+		err      bool
+	}{
+		{args: "cassandra;", user: "cassandra"},
+		{args: "cassandra 'it''s secret';", user: "cassandra", password: "it's secret"},
+		{args: "'app user' secret", user: "app user", password: "secret"},
+		{args: `"Admin"`, user: "Admin"},
+		{args: ";", err: true},
+		{args: "a b c;", err: true},
+		{args: "cassandra 'unterminated", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			user, password, err := parseLogin(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Errorf("Expected error, got: (%q, %q)", user, password)
+				}
+				return
+			}
+			if err != nil || user != tt.user || password != tt.password {
+				t.Errorf("Expected (%q, %q), got: (%q, %q, %v)", tt.user, tt.password, user, password, err)
+			}
+		})
+	}
+}
+
+func TestLoginNeedsPassword(t *testing.T) {
+	cks := &db.CQLKeyspaceSession{}
+	if err := loginCmd(cks, "cassandra;"); err == nil {
+		t.Error("Expected error without a password outside of an interactive session")
+	}
+}
+
+func TestHistoryEntry(t *testing.T) {
+	tests := []struct {
+		cmd      string
+		expected string
+	}{
+		{"select *\nfrom users;", "select * from users;"},
+		{"LOGIN cassandra 'secret';", "LOGIN cassandra;"},
+		{"login\ncassandra\nsecret;", "LOGIN cassandra;"},
+		{"login 'app user' 'it''s secret';", "LOGIN 'app user';"},
+		{"login cassandra 'unterminated", ""},
+	}
+	for _, tt := range tests {
+		if got := HistoryEntry(tt.cmd); got != tt.expected {
+			t.Errorf("Expected %q for %q, got: %q", tt.expected, tt.cmd, got)
+		}
+	}
+}
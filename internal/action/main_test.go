@@ -154,7 +154,7 @@ func TestMain(m *testing.M) {
 	}
 
 	// Create test session
-	session, closeFunc, err := db.NewSession(hostPort, 0, "", "", "test_keyspace")
+	session, closeFunc, protoVersion, err := db.NewSession(hostPort, 0, "", "", "test_keyspace", 0, nil, db.RoutingConfig{})
 	if err != nil {
 		log.Fatalf("Could not create test session: %s", err)
 	}
@@ -170,14 +170,16 @@ func TestMain(m *testing.M) {
 		IsInitialized:    true,
 		CloseSessionFunc: closeFunc,
 		TracingEnabled:   false,
+		ProtoVersion:     protoVersion,
+		Consistency:      gocql.One,
 	}
 
 	// Run tests
 	code := m.Run()
 
 	// Cleanup
-	if testSession != nil && testSession.CloseSessionFunc != nil {
-		testSession.CloseSessionFunc()
+	if testSession != nil {
+		testSession.Close()
 	}
 
 	// Only purge if we created the container (local mode)
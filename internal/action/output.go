@@ -1,50 +1,479 @@
 package action
 
 import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+	"github.com/npenkov/gcqlsh/internal/output"
+)
+
+const (
+	timestampLayout = "2006-01-02 15:04:05.000000-0700"
+	dateLayout      = "2006-01-02"
 )
 
+// rawValue keeps the serialized bytes of a column, the value is decoded
+// after scanning so that nulls, nested collections and types the driver
+// does not know can all be rendered
+type rawValue struct {
+	data []byte
+	null bool
+}
+
+func (r *rawValue) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	r.null = data == nil
+	r.data = append(r.data[:0], data...)
+	return nil
+}
+
+// mapEntry is a decoded map entry, entries keep the order of the server
+type mapEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+// rowScanner scans the columns of a row as raw values. gocql expands tuple
+// columns into one destination per element, widths keeps the number of
+// destinations of each column.
+type rowScanner struct {
+	cols   []gocql.ColumnInfo
+	dest   []interface{}
+	widths []int
+}
+
+func newRowScanner(cols []gocql.ColumnInfo) *rowScanner {
+	rs := &rowScanner{cols: cols, widths: make([]int, len(cols))}
+	for i, col := range cols {
+		rs.widths[i] = 1
+		if tt, ok := col.TypeInfo.(gocql.TupleTypeInfo); ok {
+			rs.widths[i] = len(tt.Elems)
+		}
+		for j := 0; j < rs.widths[i]; j++ {
+			rs.dest = append(rs.dest, &rawValue{})
+		}
+	}
+	return rs
+}
+
+// values returns the decoded values of the last scanned row, nil for nulls
+func (rs *rowScanner) values() ([]interface{}, error) {
+	values := make([]interface{}, len(rs.cols))
+	pos := 0
+	for i, col := range rs.cols {
+		width := rs.widths[i]
+		if tt, ok := col.TypeInfo.(gocql.TupleTypeInfo); ok {
+			tuple := make([]interface{}, width)
+			null := true
+			for j := range tuple {
+				raw := rs.dest[pos+j].(*rawValue)
+				if raw.null {
+					continue
+				}
+				null = false
+				v, err := decodeValue(tt.Elems[j], raw.data)
+				if err != nil {
+					return nil, err
+				}
+				tuple[j] = v
+			}
+			if !null {
+				values[i] = tuple
+			}
+		} else if raw := rs.dest[pos].(*rawValue); !raw.null {
+			v, err := decodeValue(col.TypeInfo, raw.data)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		pos += width
+	}
+	return values, nil
+}
+
+// readBytes reads a value prefixed with its 32 bit length, a negative
+// length marks a null value
+func readBytes(data []byte) ([]byte, []byte, error) {
+	if len(data) < 4 {
+		return nil, nil, fmt.Errorf("unexpected end of data")
+	}
+	n := int32(binary.BigEndian.Uint32(data))
+	data = data[4:]
+	if n < 0 {
+		return nil, data, nil
+	}
+	if len(data) < int(n) {
+		return nil, nil, fmt.Errorf("unexpected end of data")
+	}
+	return data[:n], data[n:], nil
+}
+
+func readCount(data []byte) (int, []byte, error) {
+	if len(data) < 4 {
+		return 0, nil, fmt.Errorf("unexpected end of data")
+	}
+	return int(int32(binary.BigEndian.Uint32(data))), data[4:], nil
+}
+
+// readVectorElement reads an element of a vector, variable length types
+// are prefixed with their length as unsigned vint
+func readVectorElement(elem gocql.TypeInfo, data []byte) ([]byte, []byte, error) {
+	n := db.FixedLength(elem)
+	if n == 0 {
+		size, read := binary.Uvarint(data)
+		if read <= 0 {
+			return nil, nil, fmt.Errorf("invalid vector element length")
+		}
+		data, n = data[read:], int(size)
+	}
+	if len(data) < n {
+		return nil, nil, fmt.Errorf("unexpected end of data")
+	}
+	return data[:n], data[n:], nil
+}
+
+// decodeValue decodes a non null serialized value. Collections, tuples, user
+// types and vectors are decoded here, leaf values are left to gocql.
+func decodeValue(info gocql.TypeInfo, data []byte) (interface{}, error) {
+	var err error
+	info = db.ResolveCustomType(info)
+	switch t := info.(type) {
+	case db.VectorType:
+		vector := make([]interface{}, t.Dimensions)
+		for i := range vector {
+			var elem []byte
+			if elem, data, err = readVectorElement(t.Elem, data); err != nil {
+				return nil, err
+			}
+			if vector[i], err = decodeValue(t.Elem, elem); err != nil {
+				return nil, err
+			}
+		}
+		return vector, nil
+	case gocql.CollectionType:
+		var n int
+		if n, data, err = readCount(data); err != nil {
+			return nil, err
+		}
+		if t.Type() == gocql.TypeMap {
+			entries := make([]mapEntry, n)
+			for i := range entries {
+				if entries[i].key, data, err = decodeElement(t.Key, data); err != nil {
+					return nil, err
+				}
+				if entries[i].value, data, err = decodeElement(t.Elem, data); err != nil {
+					return nil, err
+				}
+			}
+			return entries, nil
+		}
+		elems := make([]interface{}, n)
+		for i := range elems {
+			if elems[i], data, err = decodeElement(t.Elem, data); err != nil {
+				return nil, err
+			}
+		}
+		return elems, nil
+	case gocql.TupleTypeInfo:
+		elems := make([]interface{}, len(t.Elems))
+		for i := range elems {
+			if elems[i], data, err = decodeElement(t.Elems[i], data); err != nil {
+				return nil, err
+			}
+		}
+		return elems, nil
+	case gocql.UDTTypeInfo:
+		// fields added to the type after the value was written are missing
+		fields := make([]interface{}, len(t.Elements))
+		for i := 0; i < len(fields) && len(data) > 0; i++ {
+			if fields[i], data, err = decodeElement(t.Elements[i].Type, data); err != nil {
+				return nil, err
+			}
+		}
+		return fields, nil
+	}
+
+	if info.Type() == gocql.TypeCustom {
+		return data, nil
+	}
+	v, err := info.NewWithError()
+	if err != nil {
+		return data, nil
+	}
+	if err := gocql.Unmarshal(info, data, v); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(v).Elem().Interface(), nil
+}
+
+// decodeElement decodes a length prefixed element of a collection, tuple or
+// user type and returns the remaining data
+func decodeElement(info gocql.TypeInfo, data []byte) (interface{}, []byte, error) {
+	elem, rest, err := readBytes(data)
+	if err != nil || elem == nil {
+		return nil, rest, err
+	}
+	v, err := decodeValue(info, elem)
+	return v, rest, err
+}
+
+// printRowValue renders a column value like cqlsh does
 func printRowValue(col gocql.ColumnInfo, value interface{}) string {
-	typeMod := "s"
-	t := col.TypeInfo.Type()
-	switch t {
-	case gocql.TypeCustom:
-	case gocql.TypeAscii:
-	case gocql.TypeBigInt:
-		typeMod = "d"
-	case gocql.TypeBlob:
+	return formatValue(col.TypeInfo, value, false)
+}
+
+// formatValue renders a decoded value of the given CQL type. Values nested in
+// collections, tuples and user types are rendered as CQL literals.
+func formatValue(info gocql.TypeInfo, value interface{}, nested bool) string {
+	if value == nil {
+		return "null"
+	}
+	switch value.(type) {
+	case *inf.Dec, *big.Int:
+		if reflect.ValueOf(value).IsNil() {
+			return "null"
+		}
+	}
+
+	quote := func(s string) string {
+		if nested {
+			return quoteString(s)
+		}
+		return s
+	}
+	join := func(open string, parts []string, close string) string {
+		return open + strings.Join(parts, ", ") + close
+	}
+
+	info = db.ResolveCustomType(info)
+	switch t := info.(type) {
+	case db.VectorType:
+		if elems, ok := value.([]interface{}); ok {
+			limit := len(elems)
+			if output.MaxVectorElements > 0 && output.MaxVectorElements < limit {
+				limit = output.MaxVectorElements
+			}
+			parts := make([]string, limit)
+			for i := range parts {
+				parts[i] = formatValue(t.Elem, elems[i], true)
+			}
+			if limit < len(elems) {
+				parts = append(parts, "...")
+			}
+			return join("[", parts, "]")
+		}
+	case gocql.CollectionType:
+		if entries, ok := value.([]mapEntry); ok {
+			parts := make([]string, len(entries))
+			for i, e := range entries {
+				parts[i] = formatValue(t.Key, e.key, true) + ": " + formatValue(t.Elem, e.value, true)
+			}
+			return join("{", parts, "}")
+		}
+		if elems, ok := value.([]interface{}); ok {
+			parts := make([]string, len(elems))
+			for i, e := range elems {
+				parts[i] = formatValue(t.Elem, e, true)
+			}
+			if t.Type() == gocql.TypeSet {
+				return join("{", parts, "}")
+			}
+			return join("[", parts, "]")
+		}
+	case gocql.TupleTypeInfo:
+		if elems, ok := value.([]interface{}); ok {
+			parts := make([]string, len(elems))
+			for i, e := range elems {
+				parts[i] = formatValue(t.Elems[i], e, true)
+			}
+			return join("(", parts, ")")
+		}
+	case gocql.UDTTypeInfo:
+		if fields, ok := value.([]interface{}); ok {
+			parts := make([]string, len(fields))
+			for i, f := range fields {
+				parts[i] = quoteIdent(t.Elements[i].Name) + ": " + formatValue(t.Elements[i].Type, f, true)
+			}
+			return join("{", parts, "}")
+		}
+	}
+
+	switch info.Type() {
+	case gocql.TypeAscii, gocql.TypeText, gocql.TypeVarchar, gocql.TypeInet:
+		return quote(fmt.Sprintf("%v", value))
 	case gocql.TypeBoolean:
-		typeMod = "t"
-	case gocql.TypeCounter:
-	case gocql.TypeDecimal:
-		typeMod = "d"
-	case gocql.TypeDouble:
-		typeMod = "f"
+		if b, ok := value.(bool); ok {
+			if b {
+				return "True"
+			}
+			return "False"
+		}
 	case gocql.TypeFloat:
-		typeMod = "f"
-	case gocql.TypeInt:
-		typeMod = "d"
-	case gocql.TypeText:
+		if f, ok := value.(float32); ok {
+			return formatFloatValue(float64(f), 32)
+		}
+	case gocql.TypeDouble:
+		if f, ok := value.(float64); ok {
+			return formatFloatValue(f, 64)
+		}
 	case gocql.TypeTimestamp:
-	case gocql.TypeUUID:
-	case gocql.TypeVarchar:
-	case gocql.TypeVarint:
-	case gocql.TypeTimeUUID:
-	case gocql.TypeInet:
+		if ts, ok := value.(time.Time); ok {
+			return quote(ts.In(output.Location).Format(timestampLayout))
+		}
 	case gocql.TypeDate:
+		if ts, ok := value.(time.Time); ok {
+			return quote(ts.UTC().Format(dateLayout))
+		}
 	case gocql.TypeTime:
-	case gocql.TypeSmallInt:
-		typeMod = "d"
-	case gocql.TypeTinyInt:
-		typeMod = "d"
-	case gocql.TypeList:
-	case gocql.TypeMap:
-	case gocql.TypeSet:
-	case gocql.TypeUDT:
-	case gocql.TypeTuple:
-	}
-	val := fmt.Sprintf("%"+typeMod, value)
-	return val
+		if d, ok := value.(time.Duration); ok {
+			return quote(formatTimeOfDay(d))
+		}
+	case gocql.TypeDuration:
+		if d, ok := value.(gocql.Duration); ok {
+			return formatDuration(d)
+		}
+	}
+
+	switch v := value.(type) {
+	case fmt.Stringer:
+		return v.String()
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// jsonValue converts a decoded value of the given CQL type for encoding/json.
+// Numbers stay numbers, collections become arrays, user types and maps become
+// objects and everything else is rendered as text like cqlsh does.
+func jsonValue(info gocql.TypeInfo, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	info = db.ResolveCustomType(info)
+	switch t := info.(type) {
+	case db.VectorType:
+		if elems, ok := value.([]interface{}); ok {
+			return jsonArray(elems, func(int) gocql.TypeInfo { return t.Elem })
+		}
+	case gocql.CollectionType:
+		if entries, ok := value.([]mapEntry); ok {
+			obj := make(map[string]interface{}, len(entries))
+			for _, e := range entries {
+				obj[formatValue(t.Key, e.key, false)] = jsonValue(t.Elem, e.value)
+			}
+			return obj
+		}
+		if elems, ok := value.([]interface{}); ok {
+			return jsonArray(elems, func(int) gocql.TypeInfo { return t.Elem })
+		}
+	case gocql.TupleTypeInfo:
+		if elems, ok := value.([]interface{}); ok {
+			return jsonArray(elems, func(i int) gocql.TypeInfo { return t.Elems[i] })
+		}
+	case gocql.UDTTypeInfo:
+		if fields, ok := value.([]interface{}); ok {
+			obj := make(map[string]interface{}, len(fields))
+			for i, f := range fields {
+				obj[t.Elements[i].Name] = jsonValue(t.Elements[i].Type, f)
+			}
+			return obj
+		}
+	}
+
+	switch v := value.(type) {
+	case bool, int, int8, int16, int32, int64:
+		return v
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return formatValue(info, v, false)
+		}
+		return json.Number(formatFloatValue(float64(v), 32))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return formatValue(info, v, false)
+		}
+		return json.Number(formatFloatValue(v, 64))
+	case *big.Int:
+		if v == nil {
+			return nil
+		}
+		return json.Number(v.String())
+	case *inf.Dec:
+		if v == nil {
+			return nil
+		}
+		return json.Number(v.String())
+	}
+	return formatValue(info, value, false)
+}
+
+func jsonArray(elems []interface{}, elemType func(i int) gocql.TypeInfo) []interface{} {
+	arr := make([]interface{}, len(elems))
+	for i, e := range elems {
+		arr[i] = jsonValue(elemType(i), e)
+	}
+	return arr
+}
+
+func formatFloatValue(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
+
+// formatTimeOfDay renders nanoseconds since midnight as hh:mm:ss.nnnnnnnnn
+func formatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d:%02d.%09d",
+		int64(d/time.Hour), int64(d%time.Hour/time.Minute), int64(d%time.Minute/time.Second), int64(d%time.Second))
+}
+
+// formatDuration renders a duration the way Cassandra does, e.g. 1y2mo3d1h30m
+func formatDuration(d gocql.Duration) string {
+	if d.Months == 0 && d.Days == 0 && d.Nanoseconds == 0 {
+		return "0s"
+	}
+	var sb strings.Builder
+	months, days, nanos := int64(d.Months), int64(d.Days), d.Nanoseconds
+	if months < 0 || days < 0 || nanos < 0 {
+		sb.WriteString("-")
+		months, days, nanos = -months, -days, -nanos
+	}
+	units := []struct {
+		value int64
+		unit  string
+	}{
+		{months / 12, "y"}, {months % 12, "mo"}, {days, "d"},
+		{nanos / int64(time.Hour), "h"},
+		{nanos % int64(time.Hour) / int64(time.Minute), "m"},
+		{nanos % int64(time.Minute) / int64(time.Second), "s"},
+		{nanos % int64(time.Second) / int64(time.Millisecond), "ms"},
+		{nanos % int64(time.Millisecond) / int64(time.Microsecond), "us"},
+		{nanos % int64(time.Microsecond), "ns"},
+	}
+	for _, u := range units {
+		if u.value != 0 {
+			fmt.Fprintf(&sb, "%d%s", u.value, u.unit)
+		}
+	}
+	return sb.String()
 }
@@ -0,0 +1,248 @@
+package action
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+
+	"github.com/npenkov/gcqlsh/internal/output"
+)
+
+func nativeType(t gocql.Type) gocql.TypeInfo {
+	return gocql.NewNativeType(4, t, "")
+}
+
+func collectionType(t gocql.Type, key gocql.TypeInfo, elem gocql.TypeInfo) gocql.TypeInfo {
+	return gocql.CollectionType{NativeType: gocql.NewNativeType(4, t, ""), Key: key, Elem: elem}
+}
+
+// lengthPrefixed serializes values as the elements of a collection
+func lengthPrefixed(count int, elems ...[]byte) []byte {
+	data := binary.BigEndian.AppendUint32(nil, uint32(count))
+	for _, e := range elems {
+		if e == nil {
+			data = binary.BigEndian.AppendUint32(data, math.MaxUint32)
+			continue
+		}
+		data = binary.BigEndian.AppendUint32(data, uint32(len(e)))
+		data = append(data, e...)
+	}
+	return data
+}
+
+func mustMarshal(t *testing.T, info gocql.TypeInfo, value interface{}) []byte {
+	data, err := gocql.Marshal(info, value)
+	if err != nil {
+		t.Fatalf("Failed to marshal %v: %v", value, err)
+	}
+	return data
+}
+
+func TestFormatValue(t *testing.T) {
+	text := nativeType(gocql.TypeText)
+	intType := nativeType(gocql.TypeInt)
+	ts := time.Date(2024, 3, 5, 14, 7, 9, 123000000, time.UTC)
+
+	tests := []struct {
+		name     string
+		info     gocql.TypeInfo
+		value    interface{}
+		expected string
+	}{
+		{name: "text", info: text, value: "it's", expected: "it's"},
+		{name: "blob", info: nativeType(gocql.TypeBlob), value: []byte{0xca, 0xfe}, expected: "0xcafe"},
+		{name: "boolean", info: nativeType(gocql.TypeBoolean), value: true, expected: "True"},
+		{name: "double", info: nativeType(gocql.TypeDouble), value: 19.99, expected: "19.99"},
+		{name: "float", info: nativeType(gocql.TypeFloat), value: float32(0.1), expected: "0.1"},
+		{name: "decimal", info: nativeType(gocql.TypeDecimal), value: inf.NewDec(123456789, 4), expected: "12345.6789"},
+		{name: "varint", info: nativeType(gocql.TypeVarint), value: new(big.Int).Lsh(big.NewInt(1), 70), expected: "1180591620717411303424"},
+		{name: "timestamp", info: nativeType(gocql.TypeTimestamp), value: ts, expected: "2024-03-05 14:07:09.123000+0000"},
+		{name: "date", info: nativeType(gocql.TypeDate), value: ts, expected: "2024-03-05"},
+		{name: "time", info: nativeType(gocql.TypeTime), value: 13*time.Hour + 5*time.Second + 7, expected: "13:00:05.000000007"},
+		{name: "duration", info: nativeType(gocql.TypeDuration), value: gocql.Duration{Nanoseconds: int64(90 * time.Minute)}, expected: "1h30m"},
+		{name: "list of text", info: collectionType(gocql.TypeList, nil, text), value: []string{"a", "b'c"}, expected: "['a', 'b''c']"},
+		{name: "set of int", info: collectionType(gocql.TypeSet, nil, intType), value: []int{1, 2}, expected: "{1, 2}"},
+		{name: "map of int to text", info: collectionType(gocql.TypeMap, intType, text), value: map[int]string{9: "y"}, expected: "{9: 'y'}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := decodeValue(tt.info, mustMarshal(t, tt.info, tt.value))
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if got := formatValue(tt.info, v, false); got != tt.expected {
+				t.Errorf("Expected %s, got: %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFormatNestedValues(t *testing.T) {
+	text := nativeType(gocql.TypeText)
+	intType := nativeType(gocql.TypeInt)
+	intList := collectionType(gocql.TypeList, nil, intType)
+
+	one, two := mustMarshal(t, intType, 1), mustMarshal(t, intType, 2)
+
+	tests := []struct {
+		name     string
+		info     gocql.TypeInfo
+		data     []byte
+		expected string
+	}{
+		{
+			name:     "map with frozen list keys",
+			info:     collectionType(gocql.TypeMap, intList, text),
+			data:     lengthPrefixed(1, lengthPrefixed(2, one, two), []byte("x")),
+			expected: "{[1, 2]: 'x'}",
+		},
+		{
+			name: "tuple with null element",
+			info: gocql.TupleTypeInfo{NativeType: gocql.NewNativeType(4, gocql.TypeTuple, ""),
+				Elems: []gocql.TypeInfo{intType, text}},
+			data:     lengthPrefixed(0, one, nil)[4:],
+			expected: "(1, null)",
+		},
+		{
+			name: "udt with missing trailing field",
+			info: gocql.UDTTypeInfo{NativeType: gocql.NewNativeType(4, gocql.TypeUDT, ""), Elements: []gocql.UDTField{
+				{Name: "street", Type: text},
+				{Name: "Zip", Type: intType},
+			}},
+			data:     lengthPrefixed(0, []byte("Main"))[4:],
+			expected: `{street: 'Main', "Zip": null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := decodeValue(tt.info, tt.data)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if got := formatValue(tt.info, v, false); got != tt.expected {
+				t.Errorf("Expected %s, got: %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestJSONValue(t *testing.T) {
+	text := nativeType(gocql.TypeText)
+	intType := nativeType(gocql.TypeInt)
+
+	tests := []struct {
+		name     string
+		info     gocql.TypeInfo
+		data     []byte
+		expected string
+	}{
+		{name: "text", info: text, data: []byte(`a "b"`), expected: `"a \"b\""`},
+		{name: "int", info: intType, data: mustMarshal(t, intType, 42), expected: "42"},
+		{name: "boolean", info: nativeType(gocql.TypeBoolean), data: []byte{1}, expected: "true"},
+		{name: "double", info: nativeType(gocql.TypeDouble), data: mustMarshal(t, nativeType(gocql.TypeDouble), 19.99), expected: "19.99"},
+		{name: "double NaN", info: nativeType(gocql.TypeDouble), data: mustMarshal(t, nativeType(gocql.TypeDouble), math.NaN()), expected: `"NaN"`},
+		{name: "decimal", info: nativeType(gocql.TypeDecimal), data: mustMarshal(t, nativeType(gocql.TypeDecimal), inf.NewDec(123456789, 4)), expected: "12345.6789"},
+		{name: "blob", info: nativeType(gocql.TypeBlob), data: []byte{0xca, 0xfe}, expected: `"0xcafe"`},
+		{name: "null", info: intType, data: nil, expected: "null"},
+		{
+			name:     "list of int",
+			info:     collectionType(gocql.TypeList, nil, intType),
+			data:     mustMarshal(t, collectionType(gocql.TypeList, nil, intType), []int{1, 2}),
+			expected: "[1,2]",
+		},
+		{
+			name:     "map of int to text",
+			info:     collectionType(gocql.TypeMap, intType, text),
+			data:     mustMarshal(t, collectionType(gocql.TypeMap, intType, text), map[int]string{9: "y"}),
+			expected: `{"9":"y"}`,
+		},
+		{
+			name: "udt with missing trailing field",
+			info: gocql.UDTTypeInfo{NativeType: gocql.NewNativeType(4, gocql.TypeUDT, ""), Elements: []gocql.UDTField{
+				{Name: "street", Type: text},
+				{Name: "Zip", Type: intType},
+			}},
+			data:     lengthPrefixed(0, []byte("Main"))[4:],
+			expected: `{"Zip":null,"street":"Main"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if tt.data != nil {
+				var err error
+				if v, err = decodeValue(tt.info, tt.data); err != nil {
+					t.Fatalf("Expected no error, got: %v", err)
+				}
+			}
+			got, err := json.Marshal(jsonValue(tt.info, v))
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Expected %s, got: %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFormatVector(t *testing.T) {
+	info := gocql.NewNativeType(4, gocql.TypeCustom,
+		"org.apache.cassandra.db.marshal.VectorType(org.apache.cassandra.db.marshal.FloatType,3)")
+	var data []byte
+	for _, f := range []float32{0.1, 0.2, 0.3} {
+		data = binary.BigEndian.AppendUint32(data, math.Float32bits(f))
+	}
+
+	v, err := decodeValue(info, data)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got := formatValue(info, v, false); got != "[0.1, 0.2, 0.3]" {
+		t.Errorf("Expected [0.1, 0.2, 0.3], got: %s", got)
+	}
+
+	output.MaxVectorElements = 2
+	defer func() { output.MaxVectorElements = 0 }()
+	if got := formatValue(info, v, false); got != "[0.1, 0.2, ...]" {
+		t.Errorf("Expected truncated vector, got: %s", got)
+	}
+}
+
+func TestRowScannerNulls(t *testing.T) {
+	cols := []gocql.ColumnInfo{
+		{Name: "name", TypeInfo: nativeType(gocql.TypeText)},
+		{Name: "pair", TypeInfo: gocql.TupleTypeInfo{
+			NativeType: gocql.NewNativeType(4, gocql.TypeTuple, ""),
+			Elems:      []gocql.TypeInfo{nativeType(gocql.TypeInt), nativeType(gocql.TypeText)},
+		}},
+	}
+
+	rs := newRowScanner(cols)
+	if len(rs.dest) != 3 {
+		t.Fatalf("Expected tuple to expand into 3 destinations, got: %d", len(rs.dest))
+	}
+	for _, d := range rs.dest {
+		_ = d.(gocql.Unmarshaler).UnmarshalCQL(nil, nil)
+	}
+
+	values, err := rs.values()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if values[0] != nil || values[1] != nil {
+		t.Errorf("Expected null values, got: %v", values)
+	}
+	if got := printRowValue(cols[0], values[0]); got != "null" {
+		t.Errorf("Expected null to print as null, got: %s", got)
+	}
+}
@@ -0,0 +1,60 @@
+package action
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func pagingCmd(cks *db.CQLKeyspaceSession, paging string) error {
+	paging = strings.TrimSpace(strings.TrimSuffix(paging, ";"))
+	pageSize := cks.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	if paging == "" {
+		if cks.PagingEnabled {
+			fmt.Print("Query paging is currently enabled. Use PAGING OFF to disable\n")
+			fmt.Printf("Page size: %d\n", pageSize)
+		} else {
+			fmt.Print("Query paging is currently disabled. Use PAGING ON to enable.\n")
+		}
+		return nil
+	}
+
+	enabled, n, err := ParsePaging(paging)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !enabled:
+		cks.DisablePaging()
+		fmt.Print("Disabled Query paging.\n")
+	case n == 0:
+		cks.EnablePaging(pageSize)
+		fmt.Print("Now Query paging is enabled\n")
+		fmt.Printf("Page size: %d\n", pageSize)
+	default:
+		cks.EnablePaging(n)
+		fmt.Printf("Page size: %d\n", n)
+	}
+	return nil
+}
+
+// ParsePaging parses ON, OFF or a page size, which turns paging on as well.
+// pageSize is 0 unless it is given.
+func ParsePaging(paging string) (enabled bool, pageSize int, err error) {
+	if _, ok := matchKeywords(paging, "on"); ok {
+		return true, 0, nil
+	}
+	if _, ok := matchKeywords(paging, "off"); ok {
+		return false, 0, nil
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(paging)); err == nil && n > 0 {
+		return true, n, nil
+	}
+	return false, 0, fmt.Errorf("Improper PAGING command, use PAGING ON|OFF|<page size>")
+}
@@ -0,0 +1,69 @@
+package action
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func TestPagingCmd(t *testing.T) {
+	cks := &db.CQLKeyspaceSession{}
+
+	tests := []struct {
+		cmd      string
+		enabled  bool
+		pageSize int
+		err      bool
+	}{
+		{cmd: "on;", enabled: true, pageSize: defaultPageSize},
+		{cmd: "50", enabled: true, pageSize: 50},
+		{cmd: "OFF", enabled: false, pageSize: 50},
+		{cmd: "On", enabled: true, pageSize: 50},
+		{cmd: "-3", enabled: true, pageSize: 50, err: true},
+	}
+
+	for _, tt := range tests {
+		if err := pagingCmd(cks, tt.cmd); (err != nil) != tt.err {
+			t.Fatalf("Expected error %t for %q, got: %v", tt.err, tt.cmd, err)
+		}
+		if cks.PagingEnabled != tt.enabled || cks.PageSize != tt.pageSize {
+			t.Errorf("Expected paging %t with page size %d after %q, got: %t, %d",
+				tt.enabled, tt.pageSize, tt.cmd, cks.PagingEnabled, cks.PageSize)
+		}
+	}
+}
+
+func TestProcessCommand_PagedSelect(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+
+	if err := testSession.Session.Query("CREATE TABLE IF NOT EXISTS paged (id int PRIMARY KEY)").Exec(); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := testSession.Session.Query(fmt.Sprintf("INSERT INTO paged (id) VALUES (%d)", i)).Exec(); err != nil {
+			t.Fatalf("Failed to insert row: %v", err)
+		}
+	}
+
+	prompts := 0
+	testSession.EnablePaging(1)
+	testSession.MorePrompt = func() bool {
+		prompts++
+		return false
+	}
+	defer func() {
+		testSession.DisablePaging()
+		testSession.MorePrompt = nil
+	}()
+
+	if _, _, err := ProcessCommand("SELECT * FROM paged;", testSession); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if prompts != 1 {
+		t.Errorf("Expected output to stop at the first ---MORE--- prompt, got: %d prompts", prompts)
+	}
+}
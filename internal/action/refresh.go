@@ -0,0 +1,16 @@
+package action
+
+import (
+	"fmt"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+// refreshCmd drops the schema metadata cached for completion and DESCRIBE,
+// for changes the driver was not told about
+func refreshCmd(cks *db.CQLKeyspaceSession, args string) error {
+	if _, ok := matchKeywords(args, "schema"); !ok {
+		return fmt.Errorf("Improper REFRESH command, use REFRESH SCHEMA")
+	}
+	return cks.RefreshSchema()
+}
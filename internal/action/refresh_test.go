@@ -0,0 +1,45 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func TestRefreshNeedsSchema(t *testing.T) {
+	cks := &db.CQLKeyspaceSession{}
+	if err := refreshCmd(cks, "tables;"); err == nil {
+		t.Error("Expected error for REFRESH without SCHEMA")
+	}
+}
+
+func TestRefreshSchemaReadsChanges(t *testing.T) {
+	skipIfDockerUnavailable(t)
+
+	if _, err := testSession.FetchKeyspaceTables("test_keyspace"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// a change made behind the back of the shell, the session may not have
+	// been told about it yet
+	other, closeFunc, err := testSession.CloneSession()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	defer closeFunc()
+	if err := other.Query("CREATE TABLE test_keyspace.refreshed (id int PRIMARY KEY, name text)").Exec(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	defer other.Query("DROP TABLE IF EXISTS test_keyspace.refreshed").Exec()
+
+	if err := refreshCmd(testSession, "schema;"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	columns, err := testSession.FetchTableColumns("test_keyspace", "refreshed")
+	if err != nil {
+		t.Fatalf("Expected the new table after REFRESH SCHEMA, got: %v", err)
+	}
+	if _, ok := columns["name"]; !ok {
+		t.Errorf("Expected the columns of the new table, got: %v", columns)
+	}
+}
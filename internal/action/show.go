@@ -0,0 +1,83 @@
+package action
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+	"github.com/npenkov/gcqlsh/internal/output"
+)
+
+// ShellVersion is the gcqlsh version shown by SHOW VERSION, set from main
+var ShellVersion string
+
+func showCmd(cks *db.CQLKeyspaceSession, show string) error {
+	if _, ok := matchKeywords(show, "version"); ok {
+		v, err := cks.FetchServerVersion()
+		if err != nil {
+			return err
+		}
+		fmt.Println(versionLine(v, cks.ProtoVersion))
+		return nil
+	}
+	if _, ok := matchKeywords(show, "hosts"); ok {
+		return showHosts(cks)
+	}
+
+	return fmt.Errorf("Improper SHOW command, use SHOW VERSION|HOSTS")
+}
+
+// versionLine formats the versions the way cqlsh does, along with the
+// highest protocol version the server supports when it differs
+func versionLine(v *db.ServerVersion, protoVersion int) string {
+	shellVersion := ShellVersion
+	if shellVersion == "" {
+		shellVersion = "dev"
+	}
+	protocol := fmt.Sprintf("Native protocol v%d", protoVersion)
+	if v.NativeProtocol != "" && v.NativeProtocol != fmt.Sprint(protoVersion) {
+		protocol += fmt.Sprintf(" (server supports v%s)", v.NativeProtocol)
+	}
+	return fmt.Sprintf("[gcqlsh %s | Cassandra %s | CQL spec %s | %s]", shellVersion, v.Release, v.CQL, protocol)
+}
+
+func showHosts(cks *db.CQLKeyspaceSession) error {
+	hosts, err := cks.FetchHosts()
+	if err != nil {
+		return err
+	}
+	formatter, err := output.NewFormatter(cks.Format, os.Stdout, cks.ExpandEnabled)
+	if err != nil {
+		return err
+	}
+	cols, rows := hostRows(hosts)
+	if err := formatter.WritePage(cols, rows, 0); err != nil {
+		return err
+	}
+	return formatter.Finish(len(rows))
+}
+
+// hostRows lists the hosts as query results, the state of the nodes the
+// driver does not connect to is shown as "-"
+func hostRows(hosts []*db.HostStatus) ([]output.Column, [][]output.Value) {
+	cols := []output.Column{
+		{Name: "address", HeaderColor: output.Red, ValueColor: output.Green},
+		{Name: "datacenter", HeaderColor: output.Magenta, ValueColor: output.Green},
+		{Name: "rack", HeaderColor: output.Magenta, ValueColor: output.Green},
+		{Name: "state", HeaderColor: output.Magenta, ValueColor: output.Green},
+	}
+	rows := make([][]output.Value, len(hosts))
+	for i, h := range hosts {
+		state := output.Value{Text: "-"}
+		if h.State != "" {
+			state = output.Value{Text: h.State, JSON: h.State}
+		}
+		rows[i] = []output.Value{
+			{Text: h.Address, JSON: h.Address},
+			{Text: h.DataCenter, JSON: h.DataCenter},
+			{Text: h.Rack, JSON: h.Rack},
+			state,
+		}
+	}
+	return cols, rows
+}
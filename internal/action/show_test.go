@@ -0,0 +1,95 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func TestVersionLine(t *testing.T) {
+	v := &db.ServerVersion{Release: "4.1.3", CQL: "3.4.6", NativeProtocol: "5"}
+
+	tests := []struct {
+		name         string
+		protoVersion int
+		expected     string
+	}{
+		{name: "server supports more", protoVersion: 4,
+			expected: "[gcqlsh dev | Cassandra 4.1.3 | CQL spec 3.4.6 | Native protocol v4 (server supports v5)]"},
+		{name: "highest version", protoVersion: 5,
+			expected: "[gcqlsh dev | Cassandra 4.1.3 | CQL spec 3.4.6 | Native protocol v5]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versionLine(v, tt.protoVersion); got != tt.expected {
+				t.Errorf("Expected %s, got: %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestShowCmdImproper(t *testing.T) {
+	cks := &db.CQLKeyspaceSession{}
+	if err := showCmd(cks, "versions;"); err == nil {
+		t.Error("Expected error for an unknown SHOW command")
+	}
+}
+
+func TestProcessCommand_ShowVersion(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+
+	if testSession.ProtoVersion < 3 {
+		t.Errorf("Expected negotiated protocol version of at least 3, got: %d", testSession.ProtoVersion)
+	}
+
+	_, _, err := ProcessCommand("SHOW VERSION;", testSession)
+	if err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+}
+
+func TestHostRows(t *testing.T) {
+	hosts := []*db.HostStatus{
+		{Address: "10.0.0.1", DataCenter: "dc1", Rack: "rack1", State: "UP"},
+		{Address: "10.0.0.2", DataCenter: "dc2", Rack: "rack1"},
+	}
+	cols, rows := hostRows(hosts)
+	if len(cols) != 4 || cols[0].Name != "address" || cols[3].Name != "state" {
+		t.Errorf("Expected address, datacenter, rack and state columns, got: %v", cols)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got: %d", len(rows))
+	}
+	if rows[0][3].Text != "UP" || rows[0][3].JSON != "UP" {
+		t.Errorf("Expected state UP, got: %v", rows[0][3])
+	}
+	if rows[1][3].Text != "-" || rows[1][3].JSON != nil {
+		t.Errorf("Expected unknown state, got: %v", rows[1][3])
+	}
+}
+
+func TestProcessCommand_ShowHosts(t *testing.T) {
+	skipIfDockerUnavailable(t)
+	if testSession == nil {
+		t.Fatal("Test session is not initialized")
+	}
+
+	hosts, err := testSession.FetchHosts()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(hosts) == 0 {
+		t.Fatal("Expected at least one host")
+	}
+	if hosts[0].DataCenter == "" || hosts[0].State != "UP" {
+		t.Errorf("Expected a datacenter and state UP, got: %+v", hosts[0])
+	}
+
+	if _, _, err := ProcessCommand("SHOW HOSTS;", testSession); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+}
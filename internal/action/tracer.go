@@ -44,10 +44,14 @@ func NewTracer(cks *db.CQLKeyspaceSession) *tracer {
 }
 
 func (t *tracer) Query(stmt string, values ...interface{}) *gocql.Query {
+	q := t.cks.Session.Query(stmt, values...).Consistency(t.cks.Consistency)
+	if t.cks.SerialConsistency != 0 {
+		q = q.SerialConsistency(t.cks.SerialConsistency)
+	}
 	if t.cks.TracingEnabled {
-		return t.cks.Session.Query(stmt, values...).Trace(t.tw)
+		return q.Trace(t.tw)
 	}
-	return t.cks.Session.Query(stmt, values...)
+	return q
 }
 
 func (t *tracer) Close() {
@@ -2,36 +2,28 @@ package action
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/npenkov/gcqlsh/internal/db"
-	"github.com/npenkov/gcqlsh/internal/output"
 )
 
-func tracingCmd(cks *db.CQLKeyspaceSession, cmd string) error {
-	desc := strings.TrimPrefix(strings.TrimPrefix(cmd, "tracing "), "TRACING ")
-	desc = strings.TrimSpace(desc)
-	if strings.HasPrefix(desc, "on") || strings.HasPrefix(desc, "ON") {
+func tracingCmd(cks *db.CQLKeyspaceSession, desc string) error {
+	if _, ok := matchKeywords(desc, "on"); ok {
 		if cks.TracingEnabled {
-			output.PrintError("Tracing is already enabled. Use TRACING OFF to disable.")
-			return nil
+			return fmt.Errorf("Tracing is already enabled. Use TRACING OFF to disable")
 		}
 		cks.EnableTracing()
 		fmt.Print("Now Tracing is enabled.\n")
 		return nil
 	}
 
-	if strings.HasPrefix(desc, "off") || strings.HasPrefix(desc, "OFF") {
+	if _, ok := matchKeywords(desc, "off"); ok {
 		if !cks.TracingEnabled {
-			output.PrintError("Tracing is not enabled.")
-			return nil
+			return fmt.Errorf("Tracing is not enabled")
 		}
 		cks.DisableTracing()
 		fmt.Print("Disabled Tracing.\n")
 		return nil
 	}
 
-	output.PrintError("Improper tracing command.")
-
-	return nil
+	return fmt.Errorf("Improper TRACING command, use TRACING ON|OFF")
 }
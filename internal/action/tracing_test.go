@@ -0,0 +1,32 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func TestTracingCmd(t *testing.T) {
+	cks := &db.CQLKeyspaceSession{}
+
+	tests := []struct {
+		cmd      string
+		expected bool
+		err      bool
+	}{
+		{cmd: "ON;", expected: true},
+		{cmd: "on", expected: true, err: true},
+		{cmd: "verbose", expected: true, err: true},
+		{cmd: "Off", expected: false},
+		{cmd: "off", expected: false, err: true},
+	}
+
+	for _, tt := range tests {
+		if err := tracingCmd(cks, tt.cmd); (err != nil) != tt.err {
+			t.Fatalf("Expected error %t for %q, got: %v", tt.err, tt.cmd, err)
+		}
+		if cks.TracingEnabled != tt.expected {
+			t.Errorf("Expected tracing %t after %q, got: %t", tt.expected, tt.cmd, cks.TracingEnabled)
+		}
+	}
+}
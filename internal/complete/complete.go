@@ -0,0 +1,238 @@
+// Package complete suggests what can follow the input of the interactive
+// shell. The statement is read through a CQL grammar, so the suggestions fit
+// the position of the cursor: keywords, keyspaces, tables, the columns of
+// the table the statement refers to, types, functions and settings.
+package complete
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/npenkov/gcqlsh/internal/lexer"
+)
+
+// Source provides the names of the schema objects
+type Source interface {
+	ActiveKeyspace() string
+	Keyspaces() []string
+	Tables(keyspace string) []string
+	Columns(keyspace string, table string) []string
+	Types(keyspace string) []string
+	Functions(keyspace string) []string
+	Aggregates(keyspace string) []string
+	Views(keyspace string) []string
+	Indexes(keyspace string) []string
+}
+
+// Completer completes the input of readline
+type Completer struct {
+	Source Source
+	// Pending returns the lines of the statement entered before the current
+	// one, if any
+	Pending func() string
+
+	ConsistencyLevels       []string
+	SerialConsistencyLevels []string
+	Formats                 []string
+}
+
+// nativeTypes are the types CQL defines
+var nativeTypes = []string{
+	"ascii", "bigint", "blob", "boolean", "counter", "date", "decimal", "double", "duration", "float",
+	"inet", "int", "smallint", "text", "time", "timestamp", "timeuuid", "tinyint", "uuid", "varchar", "varint",
+}
+
+// builtinFunctions are the functions every keyspace provides
+var builtinFunctions = []string{
+	"token", "ttl", "writetime", "maxwritetime", "count", "min", "max", "sum", "avg",
+	"now", "uuid", "currentdate", "currenttime", "currenttimestamp", "currenttimeuuid",
+	"todate", "totimestamp", "tounixtimestamp", "mintimeuuid", "maxtimeuuid", "tojson", "fromjson",
+	"similarity_cosine", "similarity_euclidean", "similarity_dot_product",
+	"blobasascii", "blobasbigint", "blobasboolean", "blobasint", "blobastext", "blobasuuid",
+	"textasblob", "intasblob", "bigintasblob", "uuidasblob",
+}
+
+// Do returns the candidates completing the word before pos, without the
+// part already typed, and the length of that part
+func (c *Completer) Do(line []rune, pos int) ([][]rune, int) {
+	pending := ""
+	if c.Pending != nil {
+		pending = c.Pending()
+	}
+	text := pending + string(line[:pos])
+	_, rest := lexer.Split(text)
+	tokens := lexer.Tokenize(rest)
+
+	prefix := ""
+	if n := len(tokens); n > 0 {
+		last := tokens[n-1]
+		switch {
+		case last.Kind == lexer.Comment && !(strings.HasPrefix(last.Text, "/*") && last.Complete):
+			return nil, 0
+		case (last.Kind == lexer.String || last.Kind == lexer.DollarString) && !last.Complete:
+			return nil, 0
+		case last.Kind == lexer.Word, last.Kind == lexer.QuotedIdentifier && !last.Complete:
+			prefix = last.Text
+			tokens = tokens[:n-1]
+		}
+	}
+
+	m := &matcher{c: c, tokens: significant(tokens)}
+	statement.match(m, 0, state{}, func(int, state) {})
+
+	// the statement continues after the cursor when editing in its middle
+	after := significant(lexer.Tokenize(rest + string(line[pos:])))
+	fallbackKeyspace, fallbackTable := tableOf(after)
+
+	// the same names are often offered by several paths of the grammar
+	type lookup struct {
+		kind nameKind
+		st   state
+	}
+	looked := make(map[lookup][]string)
+	seen := make(map[string]bool)
+	candidates := make([][]rune, 0)
+	for _, s := range m.suggestions {
+		var words []string
+		if s.names == noNames {
+			words = s.words
+		} else {
+			if s.names == columnNames && s.st.table == "" {
+				s.st.tableKeyspace, s.st.table = fallbackKeyspace, fallbackTable
+			}
+			key := lookup{s.names, s.st}
+			if _, ok := looked[key]; !ok {
+				looked[key] = c.names(s.names, s.st)
+			}
+			words = looked[key]
+		}
+		for _, w := range words {
+			// choices like consistency levels follow the case of what is typed
+			if s.keyword || s.names == noNames && prefix != "" {
+				w = keywordCase(w, prefix)
+			}
+			if !hasPrefix(w, prefix) || seen[w+s.suffix] {
+				continue
+			}
+			seen[w+s.suffix] = true
+			candidates = append(candidates, []rune(w[len(prefix):]+s.suffix))
+		}
+	}
+	return candidates, len([]rune(prefix))
+}
+
+// names looks up the names of a kind of schema object, quoted as needed
+func (c *Completer) names(kind nameKind, st state) []string {
+	ks := st.keyspace
+	if ks == "" {
+		ks = c.Source.ActiveKeyspace()
+	}
+	switch kind {
+	case keyspaceNames:
+		return quoteNames(c.Source.Keyspaces())
+	case tableNames:
+		return quoteNames(c.Source.Tables(ks))
+	case columnNames:
+		if st.table == "" {
+			return nil
+		}
+		if st.tableKeyspace != "" {
+			ks = st.tableKeyspace
+		}
+		return quoteNames(c.Source.Columns(ks, st.table))
+	case typeNames:
+		if st.keyspace != "" {
+			return quoteNames(c.Source.Types(ks))
+		}
+		return append(append([]string{}, nativeTypes...), quoteNames(c.Source.Types(ks))...)
+	case userTypeNames:
+		return quoteNames(c.Source.Types(ks))
+	case functionNames:
+		names := quoteNames(append(append([]string{}, c.Source.Functions(ks)...), c.Source.Aggregates(ks)...))
+		if st.keyspace != "" {
+			return names
+		}
+		return append(append([]string{}, builtinFunctions...), names...)
+	case userFunctionNames:
+		return quoteNames(c.Source.Functions(ks))
+	case aggregateNames:
+		return quoteNames(c.Source.Aggregates(ks))
+	case viewNames:
+		return quoteNames(c.Source.Views(ks))
+	case indexNames:
+		return quoteNames(c.Source.Indexes(ks))
+	}
+	return nil
+}
+
+func significant(tokens []lexer.Token) []lexer.Token {
+	sig := make([]lexer.Token, 0, len(tokens))
+	for _, t := range tokens {
+		if t.Significant() {
+			sig = append(sig, t)
+		}
+	}
+	return sig
+}
+
+// tableOf finds the table named after FROM, INTO or UPDATE, for the columns
+// a statement lists before it
+func tableOf(tokens []lexer.Token) (keyspace string, table string) {
+	isName := func(i int) bool {
+		if i >= len(tokens) {
+			return false
+		}
+		t := tokens[i]
+		return t.Kind == lexer.Word && !reserved[strings.ToLower(t.Text)] ||
+			t.Kind == lexer.QuotedIdentifier && t.Complete
+	}
+	for i, t := range tokens {
+		if t.Kind == lexer.Symbol && t.Text == ";" {
+			break
+		}
+		if !(t.Is("from") || t.Is("into") || t.Is("update")) || !isName(i+1) {
+			continue
+		}
+		if i+2 < len(tokens) && tokens[i+2].Kind == lexer.Symbol && tokens[i+2].Text == "." && isName(i+3) {
+			return identifier(tokens[i+1].Text), identifier(tokens[i+3].Text)
+		}
+		return "", identifier(tokens[i+1].Text)
+	}
+	return "", ""
+}
+
+var unquotedName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// quoteNames quotes the names that are not valid unquoted identifiers
+func quoteNames(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		if unquotedName.MatchString(n) && !reserved[n] {
+			quoted[i] = n
+		} else {
+			quoted[i] = `"` + strings.ReplaceAll(n, `"`, `""`) + `"`
+		}
+	}
+	return quoted
+}
+
+// keywordCase writes a keyword in upper case when the word being completed
+// starts with an upper case letter
+func keywordCase(word string, prefix string) string {
+	if prefix != "" && unicode.IsUpper([]rune(prefix)[0]) {
+		return strings.ToUpper(word)
+	}
+	return strings.ToLower(word)
+}
+
+// hasPrefix compares quoted names exactly and everything else ignoring case
+func hasPrefix(word string, prefix string) bool {
+	if len(word) < len(prefix) {
+		return false
+	}
+	if strings.HasPrefix(prefix, `"`) {
+		return strings.HasPrefix(word, prefix)
+	}
+	return strings.EqualFold(word[:len(prefix)], prefix)
+}
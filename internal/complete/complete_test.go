@@ -0,0 +1,159 @@
+package complete
+
+import (
+	"testing"
+)
+
+type fakeSource struct{}
+
+func (fakeSource) ActiveKeyspace() string { return "shop" }
+func (fakeSource) Keyspaces() []string    { return []string{"shop", "system", "Audit"} }
+
+func (fakeSource) Tables(keyspace string) []string {
+	switch keyspace {
+	case "shop":
+		return []string{"orders", "users"}
+	case "system":
+		return []string{"local", "peers"}
+	}
+	return nil
+}
+
+func (fakeSource) Columns(keyspace string, table string) []string {
+	switch keyspace + "." + table {
+	case "shop.users":
+		return []string{"id", "email", "Name"}
+	case "shop.orders":
+		return []string{"order_id", "total"}
+	case "system.local":
+		return []string{"key", "release_version"}
+	}
+	return nil
+}
+
+func (fakeSource) Types(keyspace string) []string      { return []string{"address"} }
+func (fakeSource) Functions(keyspace string) []string  { return []string{"fahrenheit"} }
+func (fakeSource) Aggregates(keyspace string) []string { return []string{"average"} }
+func (fakeSource) Views(keyspace string) []string      { return []string{"users_by_email"} }
+func (fakeSource) Indexes(keyspace string) []string    { return []string{"users_email_idx"} }
+
+func newTestCompleter() *Completer {
+	return &Completer{
+		Source:                  fakeSource{},
+		ConsistencyLevels:       []string{"ONE", "QUORUM", "LOCAL_QUORUM"},
+		SerialConsistencyLevels: []string{"SERIAL", "LOCAL_SERIAL"},
+		Formats:                 []string{"table", "csv", "json"},
+	}
+}
+
+// candidates returns the completed words for the input, the cursor being at
+// its end
+func candidates(c *Completer, input string) []string {
+	line := []rune(input)
+	suffixes, length := c.Do(line, len(line))
+	prefix := string(line[len(line)-length:])
+	words := make([]string, len(suffixes))
+	for i, s := range suffixes {
+		words[i] = prefix + string(s)
+	}
+	return words
+}
+
+func TestCompleterDo(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		excluded []string
+	}{
+		{"statement keywords", "", []string{"select ", "insert ", "create ", "consistency ", "describe ", "login "}, nil},
+		{"keyword prefix", "sel", []string{"select "}, []string{"insert "}},
+		{"keyword case follows prefix", "SEL", []string{"SELECT "}, nil},
+		{"tables and keyspaces", "select * from ", []string{"orders ", "users ", "shop.", "system.", `"Audit".`}, nil},
+		{"keyspace qualified tables", "select * from system.", []string{"local ", "peers "}, []string{"orders "}},
+		{"table prefix", "SELECT * FROM us", []string{"users "}, []string{"orders "}},
+		{"columns of the table", "select * from users where ", []string{"id ", "email ", `"Name" `, "token("}, []string{"order_id "}},
+		{"columns of a qualified table", "select * from system.local where ", []string{"key ", "release_version "}, []string{"id "}},
+		{"operators", "select * from users where id ", []string{"=", "<", "<=", "in ", "contains ", "is "}, nil},
+		{"after condition", "select * from users where id = 1 ", []string{"and ", "limit ", "allow ", "order ", ";"}, []string{"where "}},
+		{"selectors and functions", "select ", []string{"*", "json ", "distinct ", "count(", "writetime(", "fahrenheit(", "average(", "cast("}, nil},
+		{"insert columns", "insert into users (", []string{"id ", "email "}, nil},
+		{"update set", "update users set ", []string{"id ", "email "}, nil},
+		{"delete columns before from", "delete ", []string{"from "}, nil},
+		{"column types", "create table t (id ", []string{"int ", "text ", "address ", "list<", "map<", "frozen<"}, nil},
+		{"nested types", "create table t (id map<text, ", []string{"int ", "set<"}, nil},
+		{"table options", "create table t (id int primary key) with ", []string{"compaction ", "gc_grace_seconds ", "clustering ", "compact "}, nil},
+		{"keyspace options", "create keyspace k with ", []string{"replication ", "durable_writes "}, nil},
+		{"consistency levels", "consistency ", []string{"ONE ", "LOCAL_QUORUM "}, nil},
+		{"consistency level prefix", "consistency lo", []string{"local_quorum "}, []string{"one "}},
+		{"serial consistency", "serial consistency ", []string{"SERIAL ", "LOCAL_SERIAL "}, nil},
+		{"formats", "format ", []string{"table ", "csv ", "json "}, nil},
+		{"describe", "desc ", []string{"keyspaces ", "table ", "materialized ", "full "}, nil},
+		{"describe view", "desc materialized view ", []string{"users_by_email "}, nil},
+		{"use keyspace", "use ", []string{"shop ", "system "}, nil},
+		{"drop index", "drop index ", []string{"users_email_idx "}, nil},
+		{"show", "show ", []string{"version ", "hosts "}, nil},
+		{"refresh", "refresh ", []string{"schema "}, nil},
+		{"copy options", "copy users to 'users.csv' with ", []string{"header ", "delimiter "}, []string{"maxbatchsize "}},
+		{"batch statements", "begin batch ", []string{"insert ", "update ", "delete ", "using "}, nil},
+		{"after batch statement", "begin batch insert into users (id) values (1); ", []string{"insert ", "apply "}, nil},
+		{"grant permission", "grant select on ", []string{"keyspace ", "all ", "users "}, nil},
+		{"inside string", "select * from users where email = 'abc", nil, []string{"and "}},
+		{"inside comment", "select * -- from ", nil, []string{"from "}},
+		{"after terminated statement", "use shop; sel", []string{"select "}, nil},
+		{"quoted prefix", `use "Au`, []string{`"Audit" `}, []string{"shop "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := candidates(newTestCompleter(), tt.input)
+			for _, e := range tt.expected {
+				if !contains(got, e) {
+					t.Errorf("Expected %q among the candidates, got: %q", e, got)
+				}
+			}
+			for _, e := range tt.excluded {
+				if contains(got, e) {
+					t.Errorf("Expected %q not to be a candidate, got: %q", e, got)
+				}
+			}
+			if tt.expected == nil && len(got) != 0 {
+				t.Errorf("Expected no candidates, got: %q", got)
+			}
+		})
+	}
+}
+
+func TestCompleterColumnsBeforeFrom(t *testing.T) {
+	c := newTestCompleter()
+	line := []rune("select  from orders")
+	// cursor between select and from
+	suffixes, length := c.Do(line, 7)
+	if length != 0 {
+		t.Fatalf("Expected an empty prefix, got length: %d", length)
+	}
+	got := make([]string, len(suffixes))
+	for i, s := range suffixes {
+		got[i] = string(s)
+	}
+	if !contains(got, "order_id ") || !contains(got, "total ") {
+		t.Errorf("Expected the columns of orders, got: %q", got)
+	}
+}
+
+func TestCompleterPendingLines(t *testing.T) {
+	c := newTestCompleter()
+	c.Pending = func() string { return "select *\nfrom users\n" }
+	got := candidates(c, "where em")
+	if len(got) != 1 || got[0] != "email " {
+		t.Errorf("Expected [email ], got: %q", got)
+	}
+}
+
+func contains(words []string, w string) bool {
+	for _, x := range words {
+		if x == w {
+			return true
+		}
+	}
+	return false
+}
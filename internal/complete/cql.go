@@ -0,0 +1,227 @@
+package complete
+
+// The grammar covers the CQL statements up to Cassandra 5 and the shell
+// commands. It is lenient where completion does not need precision: values
+// are matched as any term and option names as any word.
+
+var (
+	cqlType   node
+	selector  node
+	statement node
+)
+
+var tableOptionNames = []string{
+	"additional_write_policy", "bloom_filter_fp_chance", "caching", "cdc", "comment", "compaction",
+	"compression", "crc_check_chance", "default_time_to_live", "gc_grace_seconds", "max_index_interval",
+	"memtable", "memtable_flush_period_in_ms", "min_index_interval", "read_repair", "speculative_retry",
+}
+
+var copyToOptions = []string{"header", "delimiter", "null", "format", "pagesize", "numprocesses"}
+
+var copyFromOptions = []string{
+	"header", "delimiter", "null", "format", "numprocesses", "chunksize", "maxbatchsize",
+	"maxparseerrors", "maxinserterrors", "maxattempts", "errfile", "checkpoint",
+}
+
+func init() {
+	ifExists := opt(kw("IF", "EXISTS"))
+	ifNotExists := opt(kw("IF", "NOT", "EXISTS"))
+	types := ref(&cqlType)
+
+	cqlType = alt(
+		schemaName(typeNames),
+		seq(kwOpen("LIST", "<"), types, sym(">")),
+		seq(kwOpen("SET", "<"), types, sym(">")),
+		seq(kwOpen("MAP", "<"), types, sym(","), types, sym(">")),
+		seq(kwOpen("TUPLE", "<"), list(types), sym(">")),
+		seq(kwOpen("FROZEN", "<"), types, sym(">")),
+		seq(kwOpen("VECTOR", "<"), types, sym(","), value(), sym(">")),
+	)
+
+	function := qualified(nameNode{kind: functionNames, suffix: "(", bind: unqualify})
+	selector = seq(alt(
+		seq(kwOpen("CAST", "("), ref(&selector), kw("AS"), types, sym(")")),
+		seq(function, sym("("), opt(alt(sym("*"), list(ref(&selector)))), sym(")")),
+		column(),
+		value(),
+	), opt(kw("AS"), name()))
+
+	operator := alt(sym("="), sym("<"), sym("<="), sym(">"), sym(">="), sym("!="), kw("IN"))
+	relation := alt(
+		seq(column(), alt(operator, kw("LIKE"), seq(kw("CONTAINS"), opt(kw("KEY")))), value()),
+		seq(column(), kw("IS", "NOT", "NULL")),
+		seq(sym("("), list(column()), sym(")"), operator, value()),
+		seq(kwOpen("TOKEN", "("), list(column()), sym(")"), operator, value()),
+	)
+	where := seq(kw("WHERE"), relation, many(kw("AND"), relation))
+	condition := seq(column(), opt(sym("["), value(), sym("]")), operator, value())
+	ifClause := alt(kw("IF", "EXISTS"), seq(kw("IF"), condition, many(kw("AND"), condition)))
+	usingOption := seq(alt(kw("TTL"), kw("TIMESTAMP")), value())
+	using := seq(kw("USING"), usingOption, many(kw("AND"), usingOption))
+
+	selectStmt := seq(kw("SELECT"), opt(kw("JSON")), opt(kw("DISTINCT")),
+		alt(sym("*"), list(selector)), kw("FROM"), table(), opt(where),
+		opt(kw("GROUP", "BY"), list(column())),
+		opt(kw("ORDER", "BY"), list(seq(column(), opt(alt(kw("ASC"), kw("DESC"), seq(kw("ANN", "OF"), value())))))),
+		opt(kw("PER", "PARTITION", "LIMIT"), value()),
+		opt(kw("LIMIT"), value()),
+		opt(kw("ALLOW", "FILTERING")))
+	insertStmt := seq(kw("INSERT", "INTO"), table(), alt(
+		seq(sym("("), list(column()), sym(")"), kw("VALUES"), value()),
+		seq(kw("JSON"), value(), opt(kw("DEFAULT"), alt(kw("NULL"), kw("UNSET"))))),
+		opt(kw("IF", "NOT", "EXISTS")), opt(using))
+	assignment := seq(column(), opt(alt(seq(sym("["), value(), sym("]")), seq(sym("."), name()))), sym("="), value())
+	updateStmt := seq(kw("UPDATE"), table(), opt(using), kw("SET"), list(assignment), where, opt(ifClause))
+	deleteStmt := seq(kw("DELETE"), opt(list(seq(column(), opt(sym("["), value(), sym("]"))))),
+		kw("FROM"), table(), opt(kw("USING", "TIMESTAMP"), value()), where, opt(ifClause))
+	batchStmt := seq(kw("BEGIN"), opt(alt(kw("UNLOGGED"), kw("COUNTER"))), kw("BATCH"), opt(using),
+		many(alt(insertStmt, updateStmt, deleteStmt), sym(";")), kw("APPLY", "BATCH"))
+
+	tableOption := alt(
+		seq(choice(tableOptionNames...), sym("="), value()),
+		kw("COMPACT", "STORAGE"),
+		seq(kw("CLUSTERING", "ORDER", "BY"), sym("("), list(seq(name(), opt(alt(kw("ASC"), kw("DESC"))))), sym(")")),
+	)
+	tableOptions := seq(tableOption, many(kw("AND"), tableOption))
+	keyspaceOption := seq(choice("replication", "durable_writes"), sym("="), value())
+	keyspaceOptions := seq(keyspaceOption, many(kw("AND"), keyspaceOption))
+	roleOption := seq(choice("password", "login", "superuser", "options"), sym("="), value())
+	roleOptions := seq(roleOption, many(kw("AND"), roleOption))
+	userOptions := seq(opt(kw("WITH", "PASSWORD"), value()), opt(alt(kw("SUPERUSER"), kw("NOSUPERUSER"))))
+
+	columnDef := seq(name(), types, opt(kw("STATIC")), opt(kw("PRIMARY", "KEY")))
+	primaryKey := func(col node) node {
+		return seq(kw("PRIMARY", "KEY"), sym("("), alt(col, seq(sym("("), list(col), sym(")"))), many(sym(","), col), sym(")"))
+	}
+	userType := schemaName(userTypeNames)
+	userFunction := schemaName(userFunctionNames)
+	aggregate := schemaName(aggregateNames)
+	view := schemaName(viewNames)
+	signature := opt(sym("("), opt(list(types)), sym(")"))
+
+	createStmt := seq(kw("CREATE"), alt(
+		seq(kw("KEYSPACE"), ifNotExists, name(), kw("WITH"), keyspaceOptions),
+		seq(alt(kw("TABLE"), kw("COLUMNFAMILY")), ifNotExists, newTable(),
+			sym("("), list(alt(columnDef, primaryKey(name()))), sym(")"), opt(kw("WITH"), tableOptions)),
+		seq(opt(kw("CUSTOM")), kw("INDEX"), ifNotExists, opt(name()), kw("ON"), table(), sym("("), alt(
+			column(),
+			seq(alt(kwOpen("KEYS", "("), kwOpen("VALUES", "("), kwOpen("ENTRIES", "("), kwOpen("FULL", "(")), column(), sym(")")),
+		), sym(")"), opt(kw("USING"), value()), opt(kw("WITH", "OPTIONS"), sym("="), value())),
+		seq(kw("TYPE"), ifNotExists, newTable(), sym("("), list(seq(name(), types)), sym(")")),
+		seq(opt(kw("OR", "REPLACE")), alt(
+			seq(kw("FUNCTION"), ifNotExists, newTable(), sym("("), opt(list(seq(name(), types))), sym(")"),
+				alt(kw("CALLED"), kw("RETURNS", "NULL")), kw("ON", "NULL", "INPUT"), kw("RETURNS"), types,
+				kw("LANGUAGE"), choice("java", "javascript"), kw("AS"), value()),
+			seq(kw("AGGREGATE"), ifNotExists, newTable(), sym("("), opt(list(types)), sym(")"),
+				kw("SFUNC"), userFunction, kw("STYPE"), types,
+				opt(kw("FINALFUNC"), userFunction), opt(kw("INITCOND"), value())),
+		)),
+		seq(kw("MATERIALIZED", "VIEW"), ifNotExists, newTable(), kw("AS"), kw("SELECT"),
+			alt(sym("*"), list(column())), kw("FROM"), table(), where, primaryKey(column()),
+			opt(kw("WITH"), tableOptions)),
+		seq(kw("ROLE"), ifNotExists, name(), opt(kw("WITH"), roleOptions)),
+		seq(kw("USER"), ifNotExists, name(), userOptions),
+		seq(kw("TRIGGER"), ifNotExists, name(), kw("ON"), table(), kw("USING"), value()),
+	))
+
+	alterStmt := seq(kw("ALTER"), alt(
+		seq(kw("KEYSPACE"), ifExists, keyspace(), kw("WITH"), keyspaceOptions),
+		seq(alt(kw("TABLE"), kw("COLUMNFAMILY")), ifExists, table(), alt(
+			seq(kw("ADD"), ifNotExists, alt(columnDef, seq(sym("("), list(columnDef), sym(")")))),
+			seq(kw("DROP"), ifExists, alt(column(), seq(sym("("), list(column()), sym(")")))),
+			seq(kw("RENAME"), ifExists, column(), kw("TO"), name(), many(kw("AND"), column(), kw("TO"), name())),
+			seq(kw("ALTER"), column(), kw("TYPE"), types),
+			seq(kw("WITH"), tableOptions),
+		)),
+		seq(kw("TYPE"), ifExists, userType, alt(
+			seq(kw("ADD"), ifNotExists, name(), types),
+			seq(kw("RENAME"), ifExists, name(), kw("TO"), name(), many(kw("AND"), name(), kw("TO"), name())),
+			seq(kw("ALTER"), name(), kw("TYPE"), types),
+		)),
+		seq(kw("MATERIALIZED", "VIEW"), ifExists, view, kw("WITH"), tableOptions),
+		seq(kw("ROLE"), ifExists, name(), kw("WITH"), roleOptions),
+		seq(kw("USER"), ifExists, name(), userOptions),
+	))
+
+	dropStmt := seq(kw("DROP"), alt(
+		seq(kw("KEYSPACE"), ifExists, keyspace()),
+		seq(alt(kw("TABLE"), kw("COLUMNFAMILY")), ifExists, table()),
+		seq(kw("INDEX"), ifExists, schemaName(indexNames)),
+		seq(kw("TYPE"), ifExists, userType),
+		seq(kw("FUNCTION"), ifExists, userFunction, signature),
+		seq(kw("AGGREGATE"), ifExists, aggregate, signature),
+		seq(kw("MATERIALIZED", "VIEW"), ifExists, view),
+		seq(kw("ROLE"), ifExists, name()),
+		seq(kw("USER"), ifExists, name()),
+		seq(kw("TRIGGER"), ifExists, name(), kw("ON"), table()),
+	))
+
+	permission := alt(
+		seq(kw("ALL"), opt(kw("PERMISSIONS"))),
+		seq(alt(kw("CREATE"), kw("ALTER"), kw("DROP"), kw("SELECT"), kw("MODIFY"), kw("AUTHORIZE"),
+			kw("DESCRIBE"), kw("EXECUTE"), kw("UNMASK"), kw("SELECT_MASKED")), opt(kw("PERMISSION"))),
+	)
+	resource := alt(
+		seq(kw("ALL"), alt(kw("KEYSPACES"), kw("ROLES"), kw("MBEANS"), seq(kw("FUNCTIONS"), opt(kw("IN", "KEYSPACE"), keyspace())))),
+		seq(kw("KEYSPACE"), keyspace()),
+		seq(opt(kw("TABLE")), table()),
+		seq(kw("ROLE"), name()),
+		seq(kw("FUNCTION"), userFunction, signature),
+		seq(alt(kw("MBEAN"), kw("MBEANS")), value()),
+	)
+	grantStmt := seq(kw("GRANT"), alt(seq(permission, kw("ON"), resource, kw("TO"), name()), seq(name(), kw("TO"), name())))
+	revokeStmt := seq(kw("REVOKE"), alt(seq(permission, kw("ON"), resource, kw("FROM"), name()), seq(name(), kw("FROM"), name())))
+	listStmt := seq(kw("LIST"), alt(
+		seq(kw("ROLES"), opt(kw("OF"), name()), opt(kw("NORECURSIVE"))),
+		kw("USERS"),
+		seq(permission, opt(kw("ON"), resource), opt(kw("OF"), name()), opt(kw("NORECURSIVE"))),
+	))
+
+	describeStmt := seq(alt(kw("DESC"), kw("DESCRIBE")), alt(
+		kw("CLUSTER"),
+		kw("KEYSPACES"),
+		seq(kw("KEYSPACE"), opt(keyspace())),
+		kw("SCHEMA"),
+		kw("FULL", "SCHEMA"),
+		kw("TABLES"),
+		seq(kw("TABLE"), table()),
+		kw("TYPES"),
+		seq(kw("TYPE"), userType),
+		kw("FUNCTIONS"),
+		seq(kw("FUNCTION"), userFunction),
+		kw("AGGREGATES"),
+		seq(kw("AGGREGATE"), aggregate),
+		kw("MATERIALIZED", "VIEWS"),
+		seq(kw("MATERIALIZED", "VIEW"), view),
+		kw("INDEXES"),
+		seq(kw("INDEX"), schemaName(indexNames)),
+	))
+	copyOption := func(names []string) node {
+		option := seq(choice(names...), sym("="), value())
+		return opt(kw("WITH"), option, many(kw("AND"), option))
+	}
+	copyStmt := seq(kw("COPY"), table(), opt(sym("("), list(column()), sym(")")), alt(
+		seq(kw("TO"), alt(kw("STDOUT"), value()), copyOption(copyToOptions)),
+		seq(kw("FROM"), alt(kw("STDIN"), value()), copyOption(copyFromOptions)),
+	))
+	onOff := alt(kw("ON"), kw("OFF"))
+
+	statement = seq(alt(
+		selectStmt, insertStmt, updateStmt, deleteStmt, batchStmt,
+		seq(kw("TRUNCATE"), opt(kw("TABLE")), table()),
+		seq(kw("USE"), keyspace()),
+		createStmt, alterStmt, dropStmt, grantStmt, revokeStmt, listStmt,
+		describeStmt, copyStmt,
+		seq(kw("SHOW"), alt(kw("VERSION"), kw("HOSTS"))),
+		seq(kw("CONSISTENCY"), opt(choiceOf(func(c *Completer) []string { return c.ConsistencyLevels }))),
+		seq(kw("SERIAL", "CONSISTENCY"), opt(choiceOf(func(c *Completer) []string { return c.SerialConsistencyLevels }))),
+		seq(kw("FORMAT"), opt(choiceOf(func(c *Completer) []string { return c.Formats }))),
+		seq(kw("EXPAND"), opt(onOff)),
+		seq(kw("PAGING"), opt(alt(onOff, value()))),
+		seq(kw("TRACING"), opt(onOff)),
+		seq(kw("LOGIN"), value(), opt(value())),
+		kw("REFRESH", "SCHEMA"),
+		kw("EXIT"),
+		kw("QUIT"),
+	), opt(sym(";")))
+}
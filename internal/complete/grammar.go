@@ -0,0 +1,442 @@
+package complete
+
+import (
+	"strings"
+
+	"github.com/npenkov/gcqlsh/internal/lexer"
+)
+
+// state is what a path through the grammar has learned about the statement
+// so far
+type state struct {
+	// keyspace qualifies the next table, type or function name
+	keyspace string
+	// keyspace and table of the table the statement refers to
+	tableKeyspace string
+	table         string
+}
+
+// node is an element of the grammar. match matches the tokens starting at
+// i and calls k with every position a match can end at. A node that would
+// read a token at the cursor offers its suggestions instead.
+type node interface {
+	match(m *matcher, i int, st state, k func(i int, st state))
+}
+
+// matcher walks the tokens of a statement through the grammar, following
+// all alternatives at once
+type matcher struct {
+	c           *Completer
+	tokens      []lexer.Token
+	suggestions []suggestion
+	// steps bounds the work on statements that match in very many ways
+	steps int
+}
+
+// maxSteps is the number of nodes matched before the matcher gives up
+const maxSteps = 100000
+
+func (m *matcher) atCursor(i int) bool {
+	return i == len(m.tokens)
+}
+
+func (m *matcher) offer(s suggestion) {
+	m.suggestions = append(m.suggestions, s)
+}
+
+// suggestion is a set of candidates offered at the cursor
+type suggestion struct {
+	// words are fixed candidates like keywords and symbols
+	words []string
+	// names are looked up from the source, see nameKind
+	names nameKind
+	st    state
+	// suffix is appended to the candidates, a space for words
+	suffix string
+	// keyword candidates follow the case of the word being completed
+	keyword bool
+}
+
+type seqNode []node
+
+func seq(nodes ...node) node {
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+	return seqNode(nodes)
+}
+
+func (n seqNode) match(m *matcher, i int, st state, k func(int, state)) {
+	var step func(idx int, i int, st state)
+	step = func(idx int, i int, st state) {
+		if idx == len(n) {
+			k(i, st)
+			return
+		}
+		n[idx].match(m, i, st, func(j int, st state) {
+			step(idx+1, j, st)
+		})
+	}
+	step(0, i, st)
+}
+
+type altNode []node
+
+func alt(nodes ...node) node {
+	return altNode(nodes)
+}
+
+func (n altNode) match(m *matcher, i int, st state, k func(int, state)) {
+	for _, c := range n {
+		if m.steps++; m.steps > maxSteps {
+			return
+		}
+		c.match(m, i, st, k)
+	}
+}
+
+type optNode struct {
+	n node
+}
+
+// opt matches the sequence of nodes or nothing
+func opt(nodes ...node) node {
+	return optNode{seq(nodes...)}
+}
+
+func (n optNode) match(m *matcher, i int, st state, k func(int, state)) {
+	k(i, st)
+	n.n.match(m, i, st, k)
+}
+
+type manyNode struct {
+	n node
+}
+
+// many matches the sequence of nodes any number of times
+func many(nodes ...node) node {
+	return manyNode{seq(nodes...)}
+}
+
+func (n manyNode) match(m *matcher, i int, st state, k func(int, state)) {
+	k(i, st)
+	n.n.match(m, i, st, func(j int, st state) {
+		// a repetition has to make progress
+		if j > i {
+			if m.steps++; m.steps <= maxSteps {
+				n.match(m, j, st, k)
+			}
+		}
+	})
+}
+
+// list matches one or more of the node separated by commas
+func list(n node) node {
+	return seq(n, many(sym(","), n))
+}
+
+type refNode struct {
+	n *node
+}
+
+// ref refers to a node that is defined later, for recursive rules
+func ref(n *node) node {
+	return refNode{n}
+}
+
+func (n refNode) match(m *matcher, i int, st state, k func(int, state)) {
+	(*n.n).match(m, i, st, k)
+}
+
+type keywordNode struct {
+	word   string
+	suffix string
+}
+
+// kw matches a sequence of keywords
+func kw(words ...string) node {
+	nodes := make([]node, len(words))
+	for i, w := range words {
+		nodes[i] = keywordNode{word: w, suffix: " "}
+	}
+	return seq(nodes...)
+}
+
+// kwOpen matches a keyword that is followed by an opening symbol, like the
+// list< of a collection type
+func kwOpen(word string, open string) node {
+	return seq(keywordNode{word: word, suffix: open}, sym(open))
+}
+
+func (n keywordNode) match(m *matcher, i int, st state, k func(int, state)) {
+	if m.atCursor(i) {
+		m.offer(suggestion{words: []string{n.word}, suffix: n.suffix, keyword: true})
+		return
+	}
+	if m.tokens[i].Is(n.word) {
+		k(i+1, st)
+	}
+}
+
+type symbolNode string
+
+// sym matches symbols, which the lexer reads one character at a time
+func sym(s string) node {
+	return symbolNode(s)
+}
+
+func (n symbolNode) match(m *matcher, i int, st state, k func(int, state)) {
+	s := string(n)
+	for s != "" {
+		if m.atCursor(i) {
+			m.offer(suggestion{words: []string{s}})
+			return
+		}
+		if t := m.tokens[i]; t.Kind != lexer.Symbol || t.Text != s[:1] {
+			return
+		}
+		s = s[1:]
+		i++
+	}
+	k(i, st)
+}
+
+// nameKind is the kind of schema object a name refers to
+type nameKind int
+
+const (
+	noNames nameKind = iota
+	keyspaceNames
+	tableNames
+	columnNames
+	// typeNames are the native types and the user defined types
+	typeNames
+	userTypeNames
+	// functionNames are the built-in functions, the user defined functions
+	// and the aggregates
+	functionNames
+	userFunctionNames
+	aggregateNames
+	viewNames
+	indexNames
+)
+
+type nameNode struct {
+	kind   nameKind
+	suffix string
+	// bind records the name in the state of the path
+	bind func(st state, name string) state
+}
+
+func (n nameNode) match(m *matcher, i int, st state, k func(int, state)) {
+	if m.atCursor(i) {
+		if n.kind != noNames {
+			m.offer(suggestion{names: n.kind, st: st, suffix: n.suffix})
+		}
+		return
+	}
+	t := m.tokens[i]
+	switch {
+	case t.Kind == lexer.QuotedIdentifier && t.Complete:
+	case t.Kind == lexer.Word && !reserved[strings.ToLower(t.Text)]:
+	default:
+		return
+	}
+	if n.bind != nil {
+		st = n.bind(st, identifier(t.Text))
+	}
+	k(i+1, st)
+}
+
+// identifier returns the name of an identifier, unquoting it or lowercasing
+// it as CQL does
+func identifier(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return strings.ToLower(s)
+}
+
+// name matches the name of a new object, which is never suggested
+func name() node {
+	return nameNode{}
+}
+
+// qualifier matches the keyspace before the dot of a qualified name
+func qualifier() node {
+	return nameNode{kind: keyspaceNames, suffix: ".", bind: func(st state, name string) state {
+		st.keyspace = name
+		return st
+	}}
+}
+
+// qualified matches a name that may be prefixed with its keyspace
+func qualified(n node) node {
+	return alt(seq(qualifier(), sym("."), n), n)
+}
+
+func keyspace() node {
+	return nameNode{kind: keyspaceNames, suffix: " "}
+}
+
+// table matches a table name and makes it the table whose columns are
+// suggested
+func table() node {
+	return qualified(nameNode{kind: tableNames, suffix: " ", bind: func(st state, name string) state {
+		st.tableKeyspace, st.table, st.keyspace = st.keyspace, name, ""
+		return st
+	}})
+}
+
+// newTable matches the name of a table that is created
+func newTable() node {
+	return qualified(name())
+}
+
+func column() node {
+	return nameNode{kind: columnNames, suffix: " "}
+}
+
+// schemaName matches the name of another kind of schema object
+func schemaName(kind nameKind) node {
+	return qualified(nameNode{kind: kind, suffix: " ", bind: unqualify})
+}
+
+// unqualify drops the keyspace once the name it qualifies is read
+func unqualify(st state, name string) state {
+	st.keyspace = ""
+	return st
+}
+
+type choiceNode struct {
+	words func(c *Completer) []string
+}
+
+// choice matches any word and suggests the given ones, for option names
+// and the like
+func choice(words ...string) node {
+	return choiceNode{words: func(*Completer) []string { return words }}
+}
+
+// choiceOf is a choice among words configured on the completer
+func choiceOf(words func(c *Completer) []string) node {
+	return choiceNode{words: words}
+}
+
+func (n choiceNode) match(m *matcher, i int, st state, k func(int, state)) {
+	if m.atCursor(i) {
+		m.offer(suggestion{words: n.words(m.c), suffix: " "})
+		return
+	}
+	if m.tokens[i].Kind == lexer.Word {
+		k(i+1, st)
+	}
+}
+
+type valueNode struct{}
+
+// value matches a term: a literal, a bind marker, a function call or an
+// expression combining them. Nothing is suggested for it.
+func value() node {
+	return valueNode{}
+}
+
+func (valueNode) match(m *matcher, i int, st state, k func(int, state)) {
+	for !m.atCursor(i) {
+		t := m.tokens[i]
+		// unary sign
+		if t.Kind == lexer.Symbol && (t.Text == "-" || t.Text == "+") {
+			i++
+			continue
+		}
+		j := m.atom(i)
+		if j < 0 {
+			return
+		}
+		if m.steps++; m.steps > maxSteps {
+			return
+		}
+		k(j, st)
+		// a binary operator or a field access continues the expression
+		if j < len(m.tokens) && m.tokens[j].Kind == lexer.Symbol && strings.Contains("+-*/%.", m.tokens[j].Text) {
+			i = j + 1
+			continue
+		}
+		return
+	}
+}
+
+// atom returns the position after the term at i, -1 when there is none or
+// the cursor is inside of it
+func (m *matcher) atom(i int) int {
+	t := m.tokens[i]
+	switch {
+	case t.Kind == lexer.String || t.Kind == lexer.DollarString || t.Kind == lexer.QuotedIdentifier:
+		if !t.Complete {
+			return -1
+		}
+		i++
+	case t.Kind == lexer.Word:
+		if reserved[strings.ToLower(t.Text)] && !valueKeywords[strings.ToLower(t.Text)] {
+			return -1
+		}
+		i++
+		// function call
+		if i < len(m.tokens) && m.tokens[i].Kind == lexer.Symbol && m.tokens[i].Text == "(" {
+			return m.group(i)
+		}
+	case t.Kind == lexer.Symbol && t.Text == "?":
+		i++
+	case t.Kind == lexer.Symbol && t.Text == ":":
+		if i+1 >= len(m.tokens) || m.tokens[i+1].Kind != lexer.Word {
+			return -1
+		}
+		i += 2
+	case t.Kind == lexer.Symbol && strings.Contains("([{", t.Text):
+		return m.group(i)
+	default:
+		return -1
+	}
+	// element access
+	if i < len(m.tokens) && m.tokens[i].Kind == lexer.Symbol && m.tokens[i].Text == "[" {
+		return m.group(i)
+	}
+	return i
+}
+
+// group returns the position after the bracketed group opened at i, -1 when
+// it is not closed before the cursor
+func (m *matcher) group(i int) int {
+	depth := 0
+	for ; i < len(m.tokens); i++ {
+		t := m.tokens[i]
+		if t.Kind != lexer.Symbol {
+			continue
+		}
+		switch t.Text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// reserved are the keywords that can not be used as unquoted identifiers
+var reserved = map[string]bool{}
+
+// valueKeywords are the reserved keywords that are values
+var valueKeywords = map[string]bool{"null": true, "nan": true, "infinity": true, "token": true}
+
+func init() {
+	for _, w := range strings.Fields(`add allow alter and apply asc authorize batch begin by columnfamily
+		create delete desc describe drop entries execute from full grant if in index infinity insert into
+		keyspace limit modify nan norecursive not null of on or order primary rename replace revoke
+		schema select set table to token truncate unlogged update use using view where with`) {
+		reserved[w] = true
+	}
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Settings maps setting names, which are the names of the command line
+// options, to their values
+type Settings map[string]string
+
+// Options locates the configuration files
+type Options struct {
+	// Cqlshrc is the cqlsh configuration file, ~/.cassandra/cqlshrc when empty
+	Cqlshrc string
+	// File holds the gcqlsh profiles, ~/.gcqlsh/config when empty
+	File string
+	// Profile is the section of File to use, none when empty
+	Profile string
+}
+
+// profileKeys are the settings a profile and the environment may set
+var profileKeys = []string{
+	"host", "port", "username", "password", "password-file", "keyspace", "consistency", "serial-consistency", "protocol-version",
+	"ssl", "ssl-ca", "ssl-cert", "ssl-key", "ssl-no-verify", "local-dc", "discover-peers",
+	"no-color", "timezone", "max-vector-elements", "expand", "format", "paging",
+}
+
+var boolKeys = map[string]bool{"ssl": true, "ssl-no-verify": true, "no-color": true, "expand": true, "discover-peers": true}
+
+var pathKeys = map[string]bool{"password-file": true, "ssl-ca": true, "ssl-cert": true, "ssl-key": true}
+
+// Alternatives are settings that give the same value in different ways, a
+// source setting one of them overrides the other one of earlier sources
+var Alternatives = map[string]string{"password": "password-file", "password-file": "password"}
+
+// cqlshrcKeys maps the cqlshrc keys gcqlsh understands to settings, invert
+// marks booleans with the opposite meaning
+var cqlshrcKeys = []struct {
+	section string
+	key     string
+	setting string
+	invert  bool
+}{
+	{"connection", "hostname", "host", false},
+	{"connection", "port", "port", false},
+	{"connection", "ssl", "ssl", false},
+	{"authentication", "username", "username", false},
+	{"authentication", "password", "password", false},
+	{"authentication", "keyspace", "keyspace", false},
+	{"ssl", "certfile", "ssl-ca", false},
+	{"ssl", "usercert", "ssl-cert", false},
+	{"ssl", "userkey", "ssl-key", false},
+	{"ssl", "validate", "ssl-no-verify", true},
+	{"ui", "color", "no-color", true},
+	{"ui", "timezone", "timezone", false},
+}
+
+// cqlshEnv are the environment variables of cqlsh that are honoured when the
+// gcqlsh ones are not set
+var cqlshEnv = map[string]string{"host": "CQLSH_HOST", "port": "CQLSH_PORT", "password": "CQLSH_PASSWORD"}
+
+// EnvName is the environment variable of a setting, e.g. GCQLSH_SSL_CA
+func EnvName(setting string) string {
+	return "GCQLSH_" + strings.ToUpper(strings.ReplaceAll(setting, "-", "_"))
+}
+
+// Load reads the settings of the cqlshrc file, the profile and the
+// environment, later sources overriding earlier ones. Command line options
+// are applied on top by the caller.
+func Load(opts Options) (Settings, error) {
+	return load(opts, os.LookupEnv)
+}
+
+func load(opts Options, lookupEnv func(string) (string, bool)) (Settings, error) {
+	settings := make(Settings)
+
+	if err := loadCqlshrc(settings, opts.Cqlshrc); err != nil {
+		return nil, err
+	}
+	if opts.Profile != "" {
+		if err := loadProfile(settings, opts.File, opts.Profile); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range profileKeys {
+		value, ok := lookupEnv(EnvName(key))
+		if !ok {
+			value, ok = lookupEnv(cqlshEnv[key])
+		}
+		if ok {
+			if err := settings.set(key, value, false); err != nil {
+				return nil, fmt.Errorf("%s: %v", EnvName(key), err)
+			}
+		}
+	}
+	return settings, nil
+}
+
+func loadCqlshrc(settings Settings, file string) error {
+	ini, err := readINI(file, filepath.Join(".cassandra", "cqlshrc"))
+	if err != nil || ini == nil {
+		return err
+	}
+	for _, k := range cqlshrcKeys {
+		value, ok := ini[k.section][k.key]
+		if !ok {
+			continue
+		}
+		if err := settings.set(k.setting, value, k.invert); err != nil {
+			return fmt.Errorf("cqlshrc [%s] %s: %v", k.section, k.key, err)
+		}
+	}
+	return nil
+}
+
+func loadProfile(settings Settings, file string, profile string) error {
+	ini, err := readINI(file, filepath.Join(".gcqlsh", "config"))
+	if err != nil {
+		return err
+	}
+	section, ok := ini[profile]
+	if !ok {
+		return fmt.Errorf("profile %s not found, available profiles: %s", profile, strings.Join(ini.sections(), ", "))
+	}
+	for key, value := range section {
+		if !contains(profileKeys, key) {
+			return fmt.Errorf("profile %s: unknown setting %s", profile, key)
+		}
+		if err := settings.set(key, value, false); err != nil {
+			return fmt.Errorf("profile %s: %s: %v", profile, key, err)
+		}
+	}
+	return nil
+}
+
+// readINI parses file, or the default file relative to the home directory
+// when it is empty. A missing default file is not an error and yields nil.
+func readINI(file string, defaultFile string) (iniFile, error) {
+	explicit := file != ""
+	if !explicit {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		file = filepath.Join(home, defaultFile)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	ini, err := parseINI(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return ini, nil
+}
+
+func (ini iniFile) sections() []string {
+	names := make([]string, 0, len(ini))
+	for name := range ini {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// set stores a setting, booleans are normalized to true and false and
+// paths starting with ~ are expanded as cqlsh does
+func (s Settings) set(key string, value string, invert bool) error {
+	if boolKeys[key] {
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		value = strconv.FormatBool(b != invert)
+	}
+	if pathKeys[key] && strings.HasPrefix(value, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			value = filepath.Join(home, value[2:])
+		}
+	}
+	s[key] = value
+	delete(s, Alternatives[key])
+	return nil
+}
+
+// ReadPasswordFile returns the first line of a password file, so the
+// password is neither visible in the process list nor in the shell history
+func ReadPasswordFile(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	password, _, _ := strings.Cut(string(data), "\n")
+	password = strings.TrimSuffix(password, "\r")
+	if password == "" {
+		return "", fmt.Errorf("password file %s is empty", file)
+	}
+	return password, nil
+}
+
+// parseBool accepts the boolean spellings of configparser
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "yes", "true", "on":
+		return true, nil
+	case "0", "no", "false", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", s)
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCqlshrc = `
+; cqlsh configuration
+[authentication]
+username = cassandra
+password = secret
+keyspace = shop
+
+[connection]
+hostname = 10.0.0.1
+port = 9142
+ssl = true
+
+[ssl]
+certfile = ~/certs/ca.pem
+validate = false
+
+[ui]
+color = off
+timezone = Europe/Sofia
+`
+
+const testProfiles = `
+[prod-eu]
+host = cassandra.eu.example.com
+consistency = LOCAL_QUORUM
+ssl-no-verify = no
+
+[broken]
+colour = on
+`
+
+func writeFile(t *testing.T, name string, content string) string {
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write %s: %v", file, err)
+	}
+	return file
+}
+
+func noEnv(string) (string, bool) {
+	return "", false
+}
+
+func TestParseINI(t *testing.T) {
+	ini, err := parseINI(strings.NewReader("# comment\n[Connection]\nHostName: 127.0.0.1\nport=9042\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if ini["Connection"]["hostname"] != "127.0.0.1" || ini["Connection"]["port"] != "9042" {
+		t.Errorf("Expected hostname and port to be read, got: %v", ini)
+	}
+
+	if _, err := parseINI(strings.NewReader("port = 9042\n")); err == nil {
+		t.Error("Expected error for key outside of a section, got: nil")
+	}
+}
+
+func TestLoadCqlshrc(t *testing.T) {
+	t.Setenv("HOME", "/home/test")
+	settings, err := load(Options{Cqlshrc: writeFile(t, "cqlshrc", testCqlshrc)}, noEnv)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := Settings{
+		"username":      "cassandra",
+		"password":      "secret",
+		"keyspace":      "shop",
+		"host":          "10.0.0.1",
+		"port":          "9142",
+		"ssl":           "true",
+		"ssl-ca":        "/home/test/certs/ca.pem",
+		"ssl-no-verify": "true",
+		"no-color":      "true",
+		"timezone":      "Europe/Sofia",
+	}
+	for key, value := range expected {
+		if settings[key] != value {
+			t.Errorf("Expected %s to be %s, got: %s", key, value, settings[key])
+		}
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	opts := Options{
+		Cqlshrc: writeFile(t, "cqlshrc", testCqlshrc),
+		File:    writeFile(t, "config", testProfiles),
+		Profile: "prod-eu",
+	}
+	env := map[string]string{"GCQLSH_CONSISTENCY": "QUORUM", "CQLSH_PORT": "19042", "CQLSH_PASSWORD": "from-env"}
+	settings, err := load(opts, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := Settings{
+		"host":          "cassandra.eu.example.com",
+		"ssl-no-verify": "false",
+		"consistency":   "QUORUM",
+		"port":          "19042",
+		"username":      "cassandra",
+		"password":      "from-env",
+	}
+	for key, value := range expected {
+		if settings[key] != value {
+			t.Errorf("Expected %s to be %s, got: %s", key, value, settings[key])
+		}
+	}
+}
+
+func TestLoadPasswordAlternatives(t *testing.T) {
+	opts := Options{Cqlshrc: writeFile(t, "cqlshrc", "[authentication]\npassword = from-cqlshrc\n")}
+	env := map[string]string{"GCQLSH_PASSWORD_FILE": "/run/secrets/cassandra"}
+	settings, err := load(opts, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, ok := settings["password"]; ok {
+		t.Errorf("Expected the password file of the environment to override the cqlshrc password, got: %v", settings)
+	}
+	if settings["password-file"] != "/run/secrets/cassandra" {
+		t.Errorf("Expected the password file to be set, got: %v", settings)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	profiles := writeFile(t, "config", testProfiles)
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{name: "unknown profile", opts: Options{Cqlshrc: profiles, File: profiles, Profile: "prod-us"},
+			expected: "profile prod-us not found, available profiles: broken, prod-eu"},
+		{name: "unknown setting", opts: Options{Cqlshrc: profiles, File: profiles, Profile: "broken"},
+			expected: "unknown setting colour"},
+		{name: "missing cqlshrc", opts: Options{Cqlshrc: missing}, expected: "no such file"},
+		{name: "invalid boolean", opts: Options{Cqlshrc: writeFile(t, "cqlshrc", "[ui]\ncolor = maybe\n")},
+			expected: "invalid boolean"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(tt.opts, noEnv)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got: %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestLoadWithoutFiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	settings, err := load(Options{}, noEnv)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(settings) != 0 {
+		t.Errorf("Expected no settings, got: %v", settings)
+	}
+}
+
+func TestReadPasswordFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		err      bool
+	}{
+		{name: "single line", content: "secret", expected: "secret"},
+		{name: "trailing newline", content: "secret\n", expected: "secret"},
+		{name: "windows line ending", content: "s3 cret\r\nignored\n", expected: "s3 cret"},
+		{name: "empty", content: "\n", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			password, err := ReadPasswordFile(writeFile(t, "password", tt.content))
+			if tt.err {
+				if err == nil {
+					t.Errorf("Expected error, got: %q", password)
+				}
+				return
+			}
+			if err != nil || password != tt.expected {
+				t.Errorf("Expected %q, got: %q, %v", tt.expected, password, err)
+			}
+		})
+	}
+
+	if _, err := ReadPasswordFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
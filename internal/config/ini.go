@@ -0,0 +1,48 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// iniFile holds the sections of an INI file with their keys lowercased, as
+// Python's configparser used by cqlsh reads them
+type iniFile map[string]map[string]string
+
+func parseINI(r io.Reader) (iniFile, error) {
+	ini := make(iniFile)
+	var section map[string]string
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header %s", lineNo, line)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if ini[name] == nil {
+				ini[name] = make(map[string]string)
+			}
+			section = ini[name]
+			continue
+		}
+
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value, got %s", lineNo, line)
+		}
+		if section == nil {
+			return nil, fmt.Errorf("line %d: key %s outside of a section", lineNo, strings.TrimSpace(line[:i]))
+		}
+		section[strings.ToLower(strings.TrimSpace(line[:i]))] = strings.TrimSpace(line[i+1:])
+	}
+	return ini, scanner.Err()
+}
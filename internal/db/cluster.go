@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+)
+
+// RingHost is a node of the token ring as seen by the coordinator
+type RingHost struct {
+	Address    string
+	DataCenter string
+	Rack       string
+	Tokens     []string
+}
+
+// ClusterInfo describes the cluster the session is connected to
+type ClusterInfo struct {
+	Name        string
+	Partitioner string
+	Snitch      string
+	Hosts       []*RingHost
+}
+
+// FetchClusterInfo reads the cluster description and token ring from
+// system.local and system.peers_v2, falling back to system.peers on
+// versions before Cassandra 4.0. All of them are read from the same node,
+// which lists all others as its peers.
+func (cks *CQLKeyspaceSession) FetchClusterInfo() (*ClusterInfo, error) {
+	ctx := cks.coordinator()
+	info := &ClusterInfo{}
+	local := &RingHost{}
+	var broadcastAddress, listenAddress string
+	if err := cks.Session.Query(`SELECT cluster_name, partitioner, data_center, rack, tokens,
+		broadcast_address, listen_address FROM system.local`).WithContext(ctx).Scan(
+		&info.Name, &info.Partitioner, &local.DataCenter, &local.Rack, &local.Tokens,
+		&broadcastAddress, &listenAddress); err != nil {
+		return nil, err
+	}
+	local.Address = broadcastAddress
+	if local.Address == "" {
+		local.Address = listenAddress
+	}
+	info.Hosts = append(info.Hosts, local)
+
+	peers, err := cks.fetchPeers(ctx, "SELECT peer, data_center, rack, tokens FROM system.peers_v2")
+	if err != nil {
+		peers, err = cks.fetchPeers(ctx, "SELECT peer, data_center, rack, tokens FROM system.peers")
+		if err != nil {
+			return nil, err
+		}
+	}
+	info.Hosts = append(info.Hosts, peers...)
+
+	// The snitch is only exposed through virtual tables since Cassandra 4.0
+	info.Snitch = "unknown"
+	var snitch string
+	if err := cks.Session.Query(`SELECT value FROM system_views.settings
+		WHERE name = 'endpoint_snitch'`).WithContext(ctx).Scan(&snitch); err == nil && snitch != "" {
+		info.Snitch = snitch
+	}
+
+	return info, nil
+}
+
+func (cks *CQLKeyspaceSession) fetchPeers(ctx context.Context, stmt string) ([]*RingHost, error) {
+	peers := make([]*RingHost, 0)
+	iter := cks.Session.Query(stmt).WithContext(ctx).Iter()
+	p := &RingHost{}
+	for iter.Scan(&p.Address, &p.DataCenter, &p.Rack, &p.Tokens) {
+		peers = append(peers, p)
+		p = &RingHost{}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return peers, nil
+}
+
+// ServerVersion holds the versions reported by the node the session is
+// connected to
+type ServerVersion struct {
+	Release        string
+	CQL            string
+	NativeProtocol string
+}
+
+// FetchServerVersion reads the release, CQL and highest native protocol
+// version from system.local
+func (cks *CQLKeyspaceSession) FetchServerVersion() (*ServerVersion, error) {
+	v := &ServerVersion{}
+	if err := cks.Session.Query(`SELECT release_version, cql_version, native_protocol_version
+		FROM system.local`).Scan(&v.Release, &v.CQL, &v.NativeProtocol); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -4,9 +4,15 @@ import (
 	"github.com/gocql/gocql"
 )
 
-func IsPartitionKeyColumn(col gocql.ColumnInfo, s *gocql.Session) bool {
-	km, _ := s.KeyspaceMetadata(col.Keyspace)
-	tm := km.Tables[col.Table]
+func IsPartitionKeyColumn(col gocql.ColumnInfo, cks *CQLKeyspaceSession) bool {
+	km, err := cks.KeyspaceMetadata(col.Keyspace)
+	if err != nil {
+		return false
+	}
+	tm, ok := km.Tables[col.Table]
+	if !ok {
+		return false
+	}
 	for _, c := range tm.PartitionKey {
 		if c.Name == col.Name {
 			return true
@@ -15,9 +21,15 @@ func IsPartitionKeyColumn(col gocql.ColumnInfo, s *gocql.Session) bool {
 	return false
 }
 
-func IsClusterKeyColumn(col gocql.ColumnInfo, s *gocql.Session) bool {
-	km, _ := s.KeyspaceMetadata(col.Keyspace)
-	tm := km.Tables[col.Table]
+func IsClusterKeyColumn(col gocql.ColumnInfo, cks *CQLKeyspaceSession) bool {
+	km, err := cks.KeyspaceMetadata(col.Keyspace)
+	if err != nil {
+		return false
+	}
+	tm, ok := km.Tables[col.Table]
+	if !ok {
+		return false
+	}
 	for _, c := range tm.ClusteringColumns {
 		if c.Name == col.Name {
 			return true
@@ -27,7 +39,9 @@ func IsClusterKeyColumn(col gocql.ColumnInfo, s *gocql.Session) bool {
 }
 
 func IsStringColumn(col gocql.ColumnInfo) bool {
-	t := col.TypeInfo.Type()
+	// durations and vectors, which older drivers report as custom types,
+	// are not strings
+	t := ResolveCustomType(col.TypeInfo).Type()
 	switch t {
 	case gocql.TypeAscii:
 		return true
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocql/gocql"
+)
+
+// RoutingConfig controls which nodes of the cluster the driver talks to
+type RoutingConfig struct {
+	// LocalDC is the datacenter whose nodes coordinate the statements, the
+	// nodes of other datacenters are only used when none of them is up
+	LocalDC string
+	// DiscoverPeers connects to the nodes listed in system.peers as well,
+	// not only to the contact points
+	DiscoverPeers bool
+}
+
+// splitHosts parses a comma separated list of contact points, each of which
+// may carry its own port
+func splitHosts(hosts string, port int) ([]string, error) {
+	addrs := make([]string, 0)
+	for _, h := range strings.Split(hosts, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			addrs = append(addrs, gocql.JoinHostPort(h, port))
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no host to connect to in %q", hosts)
+	}
+	return addrs, nil
+}
+
+// hostPolicy routes statements to the replicas of their partition, falling
+// back to the nodes of the local datacenter when one is set
+func hostPolicy(routing RoutingConfig) gocql.HostSelectionPolicy {
+	fallback := gocql.RoundRobinHostPolicy()
+	if routing.LocalDC != "" {
+		fallback = gocql.DCAwareRoundRobinPolicy(routing.LocalDC)
+	}
+	return &hostTracker{HostSelectionPolicy: gocql.TokenAwareHostPolicy(fallback), hosts: make(map[string]*gocql.HostInfo)}
+}
+
+// trackers are the host trackers of the open sessions, gocql does not expose
+// the hosts it knows about otherwise
+var trackers sync.Map
+
+// hostTracker records the hosts the driver adds to and removes from its
+// host selection policy
+type hostTracker struct {
+	gocql.HostSelectionPolicy
+	mu    sync.Mutex
+	hosts map[string]*gocql.HostInfo
+}
+
+func (t *hostTracker) Init(s *gocql.Session) {
+	trackers.Store(s, t)
+	t.HostSelectionPolicy.Init(s)
+}
+
+func (t *hostTracker) AddHost(host *gocql.HostInfo) {
+	t.mu.Lock()
+	t.hosts[host.HostID()] = host
+	t.mu.Unlock()
+	t.HostSelectionPolicy.AddHost(host)
+}
+
+func (t *hostTracker) RemoveHost(host *gocql.HostInfo) {
+	t.mu.Lock()
+	delete(t.hosts, host.HostID())
+	t.mu.Unlock()
+	t.HostSelectionPolicy.RemoveHost(host)
+}
+
+func (t *hostTracker) list() []*gocql.HostInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	hosts := make([]*gocql.HostInfo, 0, len(t.hosts))
+	for _, h := range t.hosts {
+		hosts = append(hosts, h)
+	}
+	return hosts
+}
+
+// pinnedHostKey is the context key of the host a query has to be sent to
+type pinnedHostKey struct{}
+
+// onHost returns a context that sends the queries using it to host only,
+// gocql has no way to pick the coordinator of a single query otherwise
+func onHost(ctx context.Context, host *gocql.HostInfo) context.Context {
+	return context.WithValue(ctx, pinnedHostKey{}, host)
+}
+
+func (t *hostTracker) Pick(qry gocql.ExecutableQuery) gocql.NextHost {
+	if qry != nil {
+		if host, ok := qry.Context().Value(pinnedHostKey{}).(*gocql.HostInfo); ok {
+			picked := false
+			return func() gocql.SelectedHost {
+				// no other host is tried when the pinned one fails
+				if picked {
+					return nil
+				}
+				picked = true
+				return pinnedHost{host}
+			}
+		}
+	}
+	return t.HostSelectionPolicy.Pick(qry)
+}
+
+type pinnedHost struct {
+	host *gocql.HostInfo
+}
+
+func (h pinnedHost) Info() *gocql.HostInfo {
+	return h.host
+}
+
+func (h pinnedHost) Mark(error) {}
+
+// coordinator returns a context that sends queries to one node that is up,
+// for reading node local tables like system.local and system.peers
+// consistently
+func (cks *CQLKeyspaceSession) coordinator() context.Context {
+	ctx := context.Background()
+	t, ok := trackers.Load(cks.Session)
+	if !ok {
+		return ctx
+	}
+	hosts := t.(*hostTracker).list()
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].HostID() < hosts[j].HostID() })
+	for _, h := range hosts {
+		if h.IsUp() {
+			return onHost(ctx, h)
+		}
+	}
+	return ctx
+}
+
+// untrackSession forgets the hosts of a closed session
+func untrackSession(s *gocql.Session) {
+	trackers.Delete(s)
+}
+
+// HostStatus is a node of the cluster and whether the driver can reach it
+type HostStatus struct {
+	Address    string
+	DataCenter string
+	Rack       string
+	// State is UP or DOWN for the nodes the driver connects to and empty for
+	// the others
+	State string
+}
+
+// FetchHosts lists the nodes of the cluster known to the coordinator along
+// with the state the driver sees them in, sorted by datacenter, rack and
+// address
+func (cks *CQLKeyspaceSession) FetchHosts() ([]*HostStatus, error) {
+	info, err := cks.FetchClusterInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	connected := make(map[string]*gocql.HostInfo)
+	if t, ok := trackers.Load(cks.Session); ok {
+		for _, h := range t.(*hostTracker).list() {
+			for _, ip := range []net.IP{h.ConnectAddress(), h.BroadcastAddress(), h.Peer()} {
+				if ip != nil {
+					connected[ip.String()] = h
+				}
+			}
+		}
+	}
+
+	hosts := make([]*HostStatus, 0, len(info.Hosts))
+	seen := make(map[*gocql.HostInfo]bool)
+	unmatched := make([]*HostStatus, 0)
+	for _, rh := range info.Hosts {
+		status := &HostStatus{Address: rh.Address, DataCenter: rh.DataCenter, Rack: rh.Rack}
+		if h, ok := connected[rh.Address]; ok {
+			status.State = hostState(h)
+			seen[h] = true
+		} else {
+			unmatched = append(unmatched, status)
+		}
+		hosts = append(hosts, status)
+	}
+	// nodes the driver reaches under an address the coordinator does not
+	// list, e.g. a contact point behind NAT
+	rest := make([]*gocql.HostInfo, 0)
+	for _, h := range connected {
+		if !seen[h] {
+			seen[h] = true
+			rest = append(rest, h)
+		}
+	}
+	if len(rest) == 1 && len(unmatched) == 1 {
+		// the only node left on both sides has to be the same one
+		unmatched[0].State = hostState(rest[0])
+		rest = nil
+	}
+	for _, h := range rest {
+		hosts = append(hosts, &HostStatus{Address: h.ConnectAddress().String(),
+			DataCenter: h.DataCenter(), Rack: h.Rack(), State: hostState(h)})
+	}
+
+	sort.Slice(hosts, func(i, j int) bool {
+		a, b := hosts[i], hosts[j]
+		if a.DataCenter != b.DataCenter {
+			return a.DataCenter < b.DataCenter
+		}
+		if a.Rack != b.Rack {
+			return a.Rack < b.Rack
+		}
+		return a.Address < b.Address
+	})
+	return hosts, nil
+}
+
+func hostState(h *gocql.HostInfo) string {
+	if h.IsUp() {
+		return "UP"
+	}
+	return "DOWN"
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+)
+
+func TestSplitHosts(t *testing.T) {
+	tests := []struct {
+		name     string
+		hosts    string
+		expected []string
+		err      bool
+	}{
+		{"single host", "127.0.0.1", []string{"127.0.0.1:9042"}, false},
+		{"host list", "10.0.0.1, 10.0.0.2,10.0.0.3", []string{"10.0.0.1:9042", "10.0.0.2:9042", "10.0.0.3:9042"}, false},
+		{"own port", "10.0.0.1:9142,node2", []string{"10.0.0.1:9142", "node2:9042"}, false},
+		{"ipv6", "::1", []string{"[::1]:9042"}, false},
+		{"empty entries", "10.0.0.1,,", []string{"10.0.0.1:9042"}, false},
+		{"no host", " , ", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addrs, err := splitHosts(tt.hosts, 9042)
+			if tt.err {
+				if err == nil {
+					t.Errorf("Expected error, got: %v", addrs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if !reflect.DeepEqual(addrs, tt.expected) {
+				t.Errorf("Expected %v, got: %v", tt.expected, addrs)
+			}
+		})
+	}
+}
+
+func TestCreateClusterRouting(t *testing.T) {
+	cluster, err := createCluster("10.0.0.1,10.0.0.2", 9042, "", "", "", 0, nil, RoutingConfig{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(cluster.Hosts) != 2 {
+		t.Errorf("Expected 2 contact points, got: %v", cluster.Hosts)
+	}
+	if !cluster.DisableInitialHostLookup || !cluster.IgnorePeerAddr {
+		t.Errorf("Expected peer discovery to be disabled by default")
+	}
+	if _, ok := cluster.PoolConfig.HostSelectionPolicy.(*hostTracker); !ok {
+		t.Errorf("Expected the hosts to be tracked, got: %T", cluster.PoolConfig.HostSelectionPolicy)
+	}
+
+	cluster, err = createCluster("10.0.0.1", 9042, "", "", "", 0, nil, RoutingConfig{LocalDC: "dc1", DiscoverPeers: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if cluster.DisableInitialHostLookup || cluster.IgnorePeerAddr {
+		t.Errorf("Expected peer discovery to be enabled")
+	}
+}
+
+func TestHostTrackerPinnedHost(t *testing.T) {
+	tracker := hostPolicy(RoutingConfig{}).(*hostTracker)
+	host := &gocql.HostInfo{}
+	qry := (&gocql.Session{}).Query("SELECT * FROM system.local").WithContext(onHost(context.Background(), host))
+
+	next := tracker.Pick(qry)
+	if h := next(); h == nil || h.Info() != host {
+		t.Fatalf("Expected the pinned host, got: %v", h)
+	}
+	if h := next(); h != nil {
+		t.Errorf("Expected no other host to be tried, got: %v", h.Info())
+	}
+}
@@ -7,6 +7,7 @@ import (
 )
 
 type CQLKeyspaceSession struct {
+	// Host is the comma separated list of contact points
 	Host             string
 	Port             int
 	Username         string
@@ -16,7 +17,33 @@ type CQLKeyspaceSession struct {
 	NewSchema        bool
 	IsInitialized    bool
 	CloseSessionFunc func()
-	TracingEnabled   bool
+	// parked are the open sessions of the keyspaces used before, least
+	// recently used first, see UseKeyspace
+	parked []*parkedSession
+	// schemaCache holds the schema metadata read so far, see schema
+	schemaCache    *schemaCache
+	TracingEnabled bool
+	// ProtoVersion is the negotiated native protocol version
+	ProtoVersion int
+	SSL          *SSLConfig
+	Routing      RoutingConfig
+	Consistency  gocql.Consistency
+	// SerialConsistency is the consistency of the Paxos phase of lightweight
+	// transactions
+	SerialConsistency gocql.SerialConsistency
+	// PagingEnabled pauses the output of queries after every PageSize rows
+	PagingEnabled bool
+	PageSize      int
+	// MorePrompt asks whether to show the next page, it is only set in
+	// interactive mode
+	MorePrompt func() bool
+	// PasswordPrompt reads a password without echoing it, it is only set in
+	// interactive mode
+	PasswordPrompt func(prompt string) (string, error)
+	// ExpandEnabled prints every row as a vertical block of columns
+	ExpandEnabled bool
+	// Format is the output format of query results, see output.Formats
+	Format string
 }
 
 func (cks *CQLKeyspaceSession) EnableTracing() {
@@ -27,8 +54,48 @@ func (cks *CQLKeyspaceSession) DisableTracing() {
 	cks.TracingEnabled = false
 }
 
+// SetConsistency changes the consistency level of the statements
+func (cks *CQLKeyspaceSession) SetConsistency(cons gocql.Consistency) {
+	cks.Consistency = cons
+	if cks.Session != nil {
+		cks.Session.SetConsistency(cons)
+	}
+}
+
+func (cks *CQLKeyspaceSession) SetSerialConsistency(cons gocql.SerialConsistency) {
+	cks.SerialConsistency = cons
+}
+
+func (cks *CQLKeyspaceSession) EnablePaging(pageSize int) {
+	cks.PagingEnabled = true
+	cks.PageSize = pageSize
+}
+
+func (cks *CQLKeyspaceSession) DisablePaging() {
+	cks.PagingEnabled = false
+}
+
+func (cks *CQLKeyspaceSession) EnableExpand() {
+	cks.ExpandEnabled = true
+}
+
+func (cks *CQLKeyspaceSession) DisableExpand() {
+	cks.ExpandEnabled = false
+}
+
 // FetchKeyspaces obtains the list of all keyspaces available
 func (cks *CQLKeyspaceSession) FetchKeyspaces() ([]string, error) {
+	v, err := cks.schema().get("keyspaces", func() (interface{}, error) {
+		return cks.fetchKeyspaces()
+	})
+	if err != nil {
+		return nil, err
+	}
+	// callers sort the list
+	return append([]string(nil), v.([]string)...), nil
+}
+
+func (cks *CQLKeyspaceSession) fetchKeyspaces() ([]string, error) {
 	var keyspaceName string
 	keyspaces := make([]string, 0)
 	// We need to have info on what type of schema
@@ -53,9 +120,14 @@ func (cks *CQLKeyspaceSession) FetchKeyspaces() ([]string, error) {
 
 // FetchTables returns a list of all tables in the Active keyspace
 func (cks *CQLKeyspaceSession) FetchTables() ([]string, error) {
+	return cks.FetchKeyspaceTables(cks.ActiveKeyspace)
+}
+
+// FetchKeyspaceTables returns a list of all tables in the given keyspace
+func (cks *CQLKeyspaceSession) FetchKeyspaceTables(keyspace string) ([]string, error) {
 	tables := make([]string, 0)
 
-	if schema, err := cks.Session.KeyspaceMetadata(cks.ActiveKeyspace); err == nil {
+	if schema, err := cks.KeyspaceMetadata(keyspace); err == nil {
 		for table := range schema.Tables {
 			tables = append(tables, table)
 		}
@@ -65,7 +137,12 @@ func (cks *CQLKeyspaceSession) FetchTables() ([]string, error) {
 }
 
 func (cks *CQLKeyspaceSession) FetchColumns(tableName string) (map[string]*gocql.ColumnMetadata, error) {
-	schema, err := cks.Session.KeyspaceMetadata(cks.ActiveKeyspace)
+	return cks.FetchTableColumns(cks.ActiveKeyspace, tableName)
+}
+
+// FetchTableColumns returns the columns of a table in the given keyspace
+func (cks *CQLKeyspaceSession) FetchTableColumns(keyspace string, tableName string) (map[string]*gocql.ColumnMetadata, error) {
+	schema, err := cks.KeyspaceMetadata(keyspace)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,275 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrNoSystemSchema is returned for schema queries that need the
+// system_schema keyspace introduced with Cassandra 3.0
+var ErrNoSystemSchema = errors.New("schema DDL requires Cassandra 3.0+ (system_schema keyspace)")
+
+// UserType is a user defined type with its field types kept as CQL strings,
+// gocql's metadata drops the names of nested user types
+type UserType struct {
+	Keyspace   string
+	Name       string
+	FieldNames []string
+	FieldTypes []string
+}
+
+// Function is a user defined function as stored in system_schema.functions
+type Function struct {
+	Keyspace          string
+	Name              string
+	ArgumentNames     []string
+	ArgumentTypes     []string
+	Body              string
+	CalledOnNullInput bool
+	Language          string
+	ReturnType        string
+}
+
+// Aggregate is a user defined aggregate as stored in system_schema.aggregates
+type Aggregate struct {
+	Keyspace      string
+	Name          string
+	ArgumentTypes []string
+	StateFunc     string
+	StateType     string
+	FinalFunc     string
+	InitCond      string
+	ReturnType    string
+}
+
+// Index is a secondary index as stored in system_schema.indexes
+type Index struct {
+	Keyspace string
+	Table    string
+	Name     string
+	Kind     string
+	Options  map[string]string
+}
+
+// View is a materialized view definition as stored in system_schema.views
+type View struct {
+	Keyspace          string
+	Name              string
+	BaseTable         string
+	WhereClause       string
+	IncludeAllColumns bool
+}
+
+func (cks *CQLKeyspaceSession) requireSystemSchema() error {
+	if !cks.IsInitialized {
+		cks.Init()
+	}
+	if !cks.NewSchema {
+		return ErrNoSystemSchema
+	}
+	return nil
+}
+
+// FetchUserTypes returns the user defined types of a keyspace sorted by name
+func (cks *CQLKeyspaceSession) FetchUserTypes(keyspace string) ([]*UserType, error) {
+	v, err := cks.schema().get("types/"+keyspace, func() (interface{}, error) {
+		return cks.fetchUserTypes(keyspace)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]*UserType), nil
+}
+
+func (cks *CQLKeyspaceSession) fetchUserTypes(keyspace string) ([]*UserType, error) {
+	if err := cks.requireSystemSchema(); err != nil {
+		return nil, err
+	}
+	types := make([]*UserType, 0)
+	iter := cks.Session.Query(`SELECT type_name, field_names, field_types
+		FROM system_schema.types WHERE keyspace_name = ?`, keyspace).Iter()
+	ut := &UserType{Keyspace: keyspace}
+	for iter.Scan(&ut.Name, &ut.FieldNames, &ut.FieldTypes) {
+		types = append(types, ut)
+		ut = &UserType{Keyspace: keyspace}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types, nil
+}
+
+// FetchFunctions returns the user defined functions of a keyspace sorted by name
+func (cks *CQLKeyspaceSession) FetchFunctions(keyspace string) ([]*Function, error) {
+	v, err := cks.schema().get("functions/"+keyspace, func() (interface{}, error) {
+		return cks.fetchFunctions(keyspace)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]*Function), nil
+}
+
+func (cks *CQLKeyspaceSession) fetchFunctions(keyspace string) ([]*Function, error) {
+	if err := cks.requireSystemSchema(); err != nil {
+		return nil, err
+	}
+	functions := make([]*Function, 0)
+	iter := cks.Session.Query(`SELECT function_name, argument_names, argument_types, body,
+		called_on_null_input, language, return_type
+		FROM system_schema.functions WHERE keyspace_name = ?`, keyspace).Iter()
+	f := &Function{Keyspace: keyspace}
+	for iter.Scan(&f.Name, &f.ArgumentNames, &f.ArgumentTypes, &f.Body,
+		&f.CalledOnNullInput, &f.Language, &f.ReturnType) {
+		functions = append(functions, f)
+		f = &Function{Keyspace: keyspace}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(functions, func(i, j int) bool { return functions[i].Name < functions[j].Name })
+	return functions, nil
+}
+
+// FetchAggregates returns the user defined aggregates of a keyspace sorted by name
+func (cks *CQLKeyspaceSession) FetchAggregates(keyspace string) ([]*Aggregate, error) {
+	v, err := cks.schema().get("aggregates/"+keyspace, func() (interface{}, error) {
+		return cks.fetchAggregates(keyspace)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]*Aggregate), nil
+}
+
+func (cks *CQLKeyspaceSession) fetchAggregates(keyspace string) ([]*Aggregate, error) {
+	if err := cks.requireSystemSchema(); err != nil {
+		return nil, err
+	}
+	aggregates := make([]*Aggregate, 0)
+	iter := cks.Session.Query(`SELECT aggregate_name, argument_types, state_func, state_type,
+		final_func, initcond, return_type
+		FROM system_schema.aggregates WHERE keyspace_name = ?`, keyspace).Iter()
+	a := &Aggregate{Keyspace: keyspace}
+	for iter.Scan(&a.Name, &a.ArgumentTypes, &a.StateFunc, &a.StateType,
+		&a.FinalFunc, &a.InitCond, &a.ReturnType) {
+		aggregates = append(aggregates, a)
+		a = &Aggregate{Keyspace: keyspace}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(aggregates, func(i, j int) bool { return aggregates[i].Name < aggregates[j].Name })
+	return aggregates, nil
+}
+
+// FetchIndexes returns the secondary indexes of a keyspace sorted by name
+func (cks *CQLKeyspaceSession) FetchIndexes(keyspace string) ([]*Index, error) {
+	v, err := cks.schema().get("indexes/"+keyspace, func() (interface{}, error) {
+		return cks.fetchIndexes(keyspace)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]*Index), nil
+}
+
+func (cks *CQLKeyspaceSession) fetchIndexes(keyspace string) ([]*Index, error) {
+	if err := cks.requireSystemSchema(); err != nil {
+		return nil, err
+	}
+	indexes := make([]*Index, 0)
+	iter := cks.Session.Query(`SELECT table_name, index_name, kind, options
+		FROM system_schema.indexes WHERE keyspace_name = ?`, keyspace).Iter()
+	idx := &Index{Keyspace: keyspace}
+	for iter.Scan(&idx.Table, &idx.Name, &idx.Kind, &idx.Options) {
+		indexes = append(indexes, idx)
+		idx = &Index{Keyspace: keyspace}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+	return indexes, nil
+}
+
+// FetchViews returns the materialized views of a keyspace sorted by name
+func (cks *CQLKeyspaceSession) FetchViews(keyspace string) ([]*View, error) {
+	v, err := cks.schema().get("views/"+keyspace, func() (interface{}, error) {
+		return cks.fetchViews(keyspace)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]*View), nil
+}
+
+func (cks *CQLKeyspaceSession) fetchViews(keyspace string) ([]*View, error) {
+	if err := cks.requireSystemSchema(); err != nil {
+		return nil, err
+	}
+	views := make([]*View, 0)
+	iter := cks.Session.Query(`SELECT view_name, base_table_name, where_clause, include_all_columns
+		FROM system_schema.views WHERE keyspace_name = ?`, keyspace).Iter()
+	v := &View{Keyspace: keyspace}
+	for iter.Scan(&v.Name, &v.BaseTable, &v.WhereClause, &v.IncludeAllColumns) {
+		views = append(views, v)
+		v = &View{Keyspace: keyspace}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].Name < views[j].Name })
+	return views, nil
+}
+
+// FetchTableOptions returns the raw option columns of a table or a
+// materialized view. Selecting all columns keeps the result independent of
+// the options a particular Cassandra version knows about.
+func (cks *CQLKeyspaceSession) FetchTableOptions(keyspace string, name string, view bool) (map[string]interface{}, error) {
+	v, err := cks.schema().get(fmt.Sprintf("options/%s/%s/%t", keyspace, name, view), func() (interface{}, error) {
+		return cks.fetchTableOptions(keyspace, name, view)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]interface{}), nil
+}
+
+func (cks *CQLKeyspaceSession) fetchTableOptions(keyspace string, name string, view bool) (map[string]interface{}, error) {
+	if err := cks.requireSystemSchema(); err != nil {
+		return nil, err
+	}
+	stmt := "SELECT * FROM system_schema.tables WHERE keyspace_name = ? AND table_name = ?"
+	if view {
+		stmt = "SELECT * FROM system_schema.views WHERE keyspace_name = ? AND view_name = ?"
+	}
+	options := make(map[string]interface{})
+	if err := cks.Session.Query(stmt, keyspace, name).MapScan(options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+var systemKeyspaces = map[string]bool{
+	"system":                true,
+	"system_auth":           true,
+	"system_distributed":    true,
+	"system_schema":         true,
+	"system_traces":         true,
+	"system_views":          true,
+	"system_virtual_schema": true,
+	"dse_system":            true,
+	"dse_security":          true,
+	"dse_insights":          true,
+	"dse_leases":            true,
+	"dse_perf":              true,
+	"solr_admin":            true,
+}
+
+// IsSystemKeyspace reports whether a keyspace is managed by Cassandra itself
+func IsSystemKeyspace(keyspace string) bool {
+	return systemKeyspaces[keyspace]
+}
@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// schemaAgreementTimeout bounds the wait for the nodes to agree on the
+// schema before its version is compared, the cache is checked again on the
+// next use when they do not agree yet
+const schemaAgreementTimeout = time.Second
+
+// schemaCache keeps the schema metadata read for completion and DESCRIBE, so
+// that it is fetched once and not for every key press
+type schemaCache struct {
+	mu sync.Mutex
+	// dropped counts how often the entries were dropped, values loaded
+	// meanwhile are not cached
+	dropped int64
+	entries map[string]interface{}
+	// events returns the counter of the events received by the active
+	// session, see protocolObserver
+	events func() *int64
+	// version returns the schema version the nodes agree on
+	version func() (string, error)
+	// counter and seen are the event counter and its value when the schema
+	// version was last compared, schemaVersion is that version
+	counter       *int64
+	seen          int64
+	schemaVersion string
+	// metadata is the session keyspace metadata is read through. gocql
+	// caches the metadata of a session until it is told of a change, so the
+	// session is connected again whenever the entries are dropped.
+	metadata      *gocql.Session
+	closeMetadata func()
+}
+
+// get returns the cached value of key, calling load when there is none.
+// Errors are not cached.
+func (c *schemaCache) get(key string, load func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	if err := c.check(); err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	v, ok := c.entries[key]
+	dropped := c.dropped
+	c.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	v, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	// the schema may have changed while it was loaded
+	if c.dropped == dropped {
+		c.entries[key] = v
+	}
+	c.mu.Unlock()
+	return v, nil
+}
+
+// check drops the entries when the active session received an event since
+// the schema version was last compared and the version changed. The frame
+// header of an event does not tell a schema change from a node going up or
+// down, comparing the version leaves the entries alone for the latter.
+// c.mu is held by the caller.
+func (c *schemaCache) check() error {
+	if c.entries == nil {
+		c.entries = make(map[string]interface{})
+	}
+	counter := c.events()
+	if counter == c.counter && atomic.LoadInt64(counter) == c.seen {
+		return nil
+	}
+	// events received while the version is read are compared next time
+	seen := atomic.LoadInt64(counter)
+	version, err := c.version()
+	if err != nil {
+		return err
+	}
+	if version != c.schemaVersion {
+		c.drop()
+	}
+	c.counter, c.seen, c.schemaVersion = counter, seen, version
+	return nil
+}
+
+// drop forgets the entries and the keyspace metadata of gocql, c.mu is held
+// by the caller
+func (c *schemaCache) drop() {
+	c.entries = make(map[string]interface{})
+	c.dropped++
+	c.closeSession()
+}
+
+// session returns the session to read keyspace metadata through, calling
+// connect when there is none
+func (c *schemaCache) session(connect func() (*gocql.Session, func(), error)) (*gocql.Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.metadata == nil {
+		s, closeFunc, err := connect()
+		if err != nil {
+			return nil, err
+		}
+		c.metadata, c.closeMetadata = s, closeFunc
+	}
+	return c.metadata, nil
+}
+
+// closeSession closes the metadata session, c.mu is held by the caller
+func (c *schemaCache) closeSession() {
+	if c.closeMetadata != nil {
+		c.closeMetadata()
+	}
+	c.metadata, c.closeMetadata = nil, nil
+}
+
+func (cks *CQLKeyspaceSession) schema() *schemaCache {
+	if cks.schemaCache == nil {
+		cks.schemaCache = &schemaCache{events: cks.schemaEvents, version: cks.schemaVersion}
+	}
+	return cks.schemaCache
+}
+
+// schemaEvents returns the event counter of the active session
+func (cks *CQLKeyspaceSession) schemaEvents() *int64 {
+	if o, ok := observers.Load(cks.Session); ok {
+		return &o.(*protocolObserver).events
+	}
+	return &unobserved
+}
+
+// unobserved is the event counter of sessions without an observer, it never
+// advances
+var unobserved int64
+
+// schemaVersion waits for the nodes to agree on the schema and returns its
+// version
+func (cks *CQLKeyspaceSession) schemaVersion() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), schemaAgreementTimeout)
+	defer cancel()
+	if err := cks.Session.AwaitSchemaAgreement(ctx); err != nil {
+		return "", err
+	}
+	var version gocql.UUID
+	if err := cks.Session.Query("SELECT schema_version FROM system.local").Scan(&version); err != nil {
+		return "", err
+	}
+	return version.String(), nil
+}
+
+// InvalidateSchema drops the cached schema, e.g. after DDL run in the shell
+func (cks *CQLKeyspaceSession) InvalidateSchema() {
+	c := cks.schema()
+	c.mu.Lock()
+	c.drop()
+	c.mu.Unlock()
+}
+
+// RefreshSchema drops the cached schema, including the keyspace metadata
+// gocql keeps, and reads the keyspaces again
+func (cks *CQLKeyspaceSession) RefreshSchema() error {
+	cks.InvalidateSchema()
+	_, err := cks.FetchKeyspaces()
+	return err
+}
+
+// KeyspaceMetadata returns the metadata of a keyspace from the schema cache.
+// It is read through a session of its own, which is connected again after
+// the schema changed.
+func (cks *CQLKeyspaceSession) KeyspaceMetadata(keyspace string) (*gocql.KeyspaceMetadata, error) {
+	c := cks.schema()
+	v, err := c.get("metadata/"+keyspace, func() (interface{}, error) {
+		s, err := c.session(func() (*gocql.Session, func(), error) {
+			s, closeFunc, _, err := NewSession(cks.Host, cks.Port, cks.Username, cks.Password, "", cks.ProtoVersion, cks.SSL, cks.Routing)
+			return s, closeFunc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return s.KeyspaceMetadata(keyspace)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*gocql.KeyspaceMetadata), nil
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+// testCache returns a cache whose events and schema version are set by the
+// test
+func testCache(events *int64, version *string) *schemaCache {
+	return &schemaCache{
+		events:  func() *int64 { return events },
+		version: func() (string, error) { return *version, nil },
+	}
+}
+
+func TestSchemaCache(t *testing.T) {
+	events, version := int64(0), "v1"
+	c := testCache(&events, &version)
+	loads := 0
+	load := func() (interface{}, error) {
+		loads++
+		return []string{"shop"}, nil
+	}
+	get := func() {
+		t.Helper()
+		if _, err := c.get("keyspaces", load); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		get()
+	}
+	if loads != 1 {
+		t.Errorf("Expected 1 load, got: %d", loads)
+	}
+
+	// a node event leaves the schema version alone
+	events++
+	get()
+	if loads != 1 {
+		t.Errorf("Expected the schema to stay cached after an event that did not change it, got %d loads", loads)
+	}
+
+	events++
+	version = "v2"
+	get()
+	get()
+	if loads != 2 {
+		t.Errorf("Expected the schema to be loaded again once after a change, got %d loads", loads)
+	}
+
+	// the version is only compared after an event
+	version = "v3"
+	get()
+	if loads != 2 {
+		t.Errorf("Expected no load without an event, got %d loads", loads)
+	}
+}
+
+func TestSchemaCacheOtherSession(t *testing.T) {
+	events, other, version := int64(1), int64(1), "v1"
+	counter := &events
+	c := &schemaCache{
+		events:  func() *int64 { return counter },
+		version: func() (string, error) { return version, nil },
+	}
+	loads := 0
+	load := func() (interface{}, error) {
+		loads++
+		return "loaded", nil
+	}
+	c.get("keyspaces", load)
+
+	// the counter of another session is compared on its own
+	counter, version = &other, "v2"
+	c.get("keyspaces", load)
+	if loads != 2 {
+		t.Errorf("Expected the version to be compared after switching sessions, got %d loads", loads)
+	}
+}
+
+func TestSchemaCacheDrop(t *testing.T) {
+	events, version := int64(0), "v1"
+	c := testCache(&events, &version)
+	loads := 0
+	load := func() (interface{}, error) {
+		loads++
+		return "loaded", nil
+	}
+	c.get("keyspaces", load)
+	c.mu.Lock()
+	c.drop()
+	c.mu.Unlock()
+	c.get("keyspaces", load)
+	if loads != 2 {
+		t.Errorf("Expected the schema to be loaded again after it was dropped, got %d loads", loads)
+	}
+}
+
+func TestSchemaCacheErrors(t *testing.T) {
+	events, version := int64(0), "v1"
+	c := testCache(&events, &version)
+	fail := func() (interface{}, error) {
+		return nil, errors.New("unavailable")
+	}
+	if _, err := c.get("keyspaces", fail); err == nil {
+		t.Error("Expected the load error")
+	}
+	v, err := c.get("keyspaces", func() (interface{}, error) { return "loaded", nil })
+	if err != nil || v != "loaded" {
+		t.Errorf("Expected errors not to be cached, got: %v, %v", v, err)
+	}
+}
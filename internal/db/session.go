@@ -1,13 +1,51 @@
 package db
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gocql/gocql"
 )
 
-func createCluster(host string, port int, username string, password string, keyspace string) *gocql.ClusterConfig {
-	cluster := gocql.NewCluster(gocql.JoinHostPort(host, port))
+// MaxProtoVersion is the highest native protocol version the driver speaks.
+// Version 5 is only available as a beta protocol in gocql.
+const MaxProtoVersion = 4
+
+// eventOpcode is the opcode of the frames the server pushes on stream -1
+const eventOpcode = 0x0c
+
+// observers are the protocol observers of the open sessions
+var observers sync.Map
+
+// protocolObserver records the protocol version of the frames received from
+// the server, which is the version negotiated by the driver. It also counts
+// the events the server pushes, see schemaCache.check.
+type protocolObserver struct {
+	version int32
+	events  int64
+}
+
+func (o *protocolObserver) ObserveFrameHeader(_ context.Context, h gocql.ObservedFrameHeader) {
+	atomic.StoreInt32(&o.version, int32(h.Version&0x7f))
+	if h.Stream == -1 && h.Opcode == eventOpcode {
+		atomic.AddInt64(&o.events, 1)
+	}
+}
+
+func createCluster(hosts string, port int, username string, password string, keyspace string, protoVersion int, ssl *SSLConfig, routing RoutingConfig) (*gocql.ClusterConfig, error) {
+	sslOpts, err := ssl.sslOptions()
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := splitHosts(hosts, port)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := gocql.NewCluster(addrs...)
 
 	if username != "" && password != "" {
 		cluster.Authenticator = gocql.PasswordAuthenticator{
@@ -20,26 +58,159 @@ func createCluster(host string, port int, username string, password string, keys
 	cluster.Consistency = gocql.One
 	cluster.Timeout = 10 * time.Second
 	cluster.MaxWaitSchemaAgreement = 2 * time.Minute
-	cluster.ProtoVersion = 3
-	cluster.IgnorePeerAddr = true
-	cluster.DisableInitialHostLookup = true
+	// 0 lets the driver negotiate the highest version supported by the server
+	cluster.ProtoVersion = protoVersion
+	// without discovery only the contact points are connected to
+	cluster.IgnorePeerAddr = !routing.DiscoverPeers
+	cluster.DisableInitialHostLookup = !routing.DiscoverPeers
+	cluster.PoolConfig.HostSelectionPolicy = hostPolicy(routing)
 
 	cluster.NumConns = 3
+	cluster.SslOpts = sslOpts
 
-	return cluster
+	return cluster, nil
 }
 
-func createSession(cluster *gocql.ClusterConfig) (*gocql.Session, func(), error) {
+func createSession(cluster *gocql.ClusterConfig) (*gocql.Session, func(), int, error) {
+	if cluster.ProtoVersion < 0 || cluster.ProtoVersion > MaxProtoVersion {
+		return nil, nil, 0, fmt.Errorf("unsupported native protocol version %d, use 1 to %d or 0 to negotiate",
+			cluster.ProtoVersion, MaxProtoVersion)
+	}
+	observer := &protocolObserver{}
+	cluster.FrameHeaderObserver = observer
 	session, err := cluster.CreateSession()
+	if err != nil && cluster.SslOpts != nil {
+		err = sslError(err, cluster.Hosts[0])
+	}
+	if err == nil {
+		observers.Store(session, observer)
+	}
 	return session, func() {
+		untrackSession(session)
+		observers.Delete(session)
 		session.Close()
-	}, err
+	}, int(atomic.LoadInt32(&observer.version)), err
 }
 
-func NewSession(host string, port int, username string, password string, keyspace string) (*gocql.Session, func(), error) {
-	return createSession(createCluster(host, port, username, password, keyspace))
+// NewSession connects to the cluster and returns the session together with
+// the native protocol version in use. hosts is a comma separated list of
+// contact points. A protoVersion of 0 negotiates it, a nil ssl connects
+// without TLS.
+func NewSession(hosts string, port int, username string, password string, keyspace string, protoVersion int, ssl *SSLConfig, routing RoutingConfig) (*gocql.Session, func(), int, error) {
+	cluster, err := createCluster(hosts, port, username, password, keyspace, protoVersion, ssl, routing)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return createSession(cluster)
 }
 
 func (cks *CQLKeyspaceSession) CloneSession() (*gocql.Session, func(), error) {
-	return createSession(createCluster(cks.Host, cks.Port, cks.Username, cks.Password, cks.ActiveKeyspace))
+	session, closeFunc, _, err := NewSession(cks.Host, cks.Port, cks.Username, cks.Password, cks.ActiveKeyspace, cks.ProtoVersion, cks.SSL, cks.Routing)
+	if err == nil {
+		session.SetConsistency(cks.Consistency)
+	}
+	return session, closeFunc, err
+}
+
+// maxParkedSessions is the number of sessions of keyspaces used before that
+// stay open. Every session holds a pool of connections to each host, so
+// the least recently used one is closed beyond it.
+const maxParkedSessions = 3
+
+// parkedSession is a session kept open for switching back to its keyspace
+type parkedSession struct {
+	keyspace  string
+	session   *gocql.Session
+	closeFunc func()
+}
+
+// UseKeyspace makes keyspace the active one. gocql can neither change the
+// keyspace of the pooled connections nor send the keyspace along with the
+// statements, so every keyspace has a session of its own. Connecting it
+// takes as long as starting the shell the first time a keyspace is used.
+// The sessions of the last maxParkedSessions keyspaces used before stay
+// open, switching back to them is instant.
+func (cks *CQLKeyspaceSession) UseKeyspace(keyspace string) error {
+	if keyspace == cks.ActiveKeyspace && cks.Session != nil {
+		return nil
+	}
+	keyspaces, err := cks.FetchKeyspaces()
+	if err != nil {
+		return err
+	}
+	exists := false
+	for _, ks := range keyspaces {
+		exists = exists || ks == keyspace
+	}
+	if !exists {
+		return fmt.Errorf("Keyspace '%s' does not exist", keyspace)
+	}
+
+	next := cks.unpark(keyspace)
+	if next == nil {
+		s, closeFunc, _, err := NewSession(cks.Host, cks.Port, cks.Username, cks.Password, keyspace, cks.ProtoVersion, cks.SSL, cks.Routing)
+		if err != nil {
+			return err
+		}
+		next = &parkedSession{keyspace: keyspace, session: s, closeFunc: closeFunc}
+	}
+
+	if cks.Session != nil {
+		cks.park(&parkedSession{keyspace: cks.ActiveKeyspace, session: cks.Session, closeFunc: cks.CloseSessionFunc})
+	}
+	cks.Session, cks.CloseSessionFunc = next.session, next.closeFunc
+	cks.Session.SetConsistency(cks.Consistency)
+	cks.ActiveKeyspace = keyspace
+	return nil
+}
+
+// unpark takes the parked session of keyspace, nil when there is none
+func (cks *CQLKeyspaceSession) unpark(keyspace string) *parkedSession {
+	for i, p := range cks.parked {
+		if p.keyspace == keyspace {
+			cks.parked = append(cks.parked[:i], cks.parked[i+1:]...)
+			return p
+		}
+	}
+	return nil
+}
+
+// park keeps a session open, closing the least recently used one when too
+// many are
+func (cks *CQLKeyspaceSession) park(p *parkedSession) {
+	cks.parked = append(cks.parked, p)
+	if len(cks.parked) > maxParkedSessions {
+		if oldest := cks.parked[0]; oldest.closeFunc != nil {
+			oldest.closeFunc()
+		}
+		cks.parked = cks.parked[1:]
+	}
+}
+
+// ReplaceSession continues with a new session of the active keyspace,
+// closing all others, e.g. after logging in as another user
+func (cks *CQLKeyspaceSession) ReplaceSession(s *gocql.Session, closeFunc func()) {
+	cks.Close()
+	s.SetConsistency(cks.Consistency)
+	cks.Session, cks.CloseSessionFunc = s, closeFunc
+}
+
+// Close closes the session along with the sessions of the keyspaces used
+// before
+func (cks *CQLKeyspaceSession) Close() {
+	for _, p := range cks.parked {
+		if p.closeFunc != nil {
+			p.closeFunc()
+		}
+	}
+	cks.parked = nil
+	if cks.schemaCache != nil {
+		cks.schemaCache.mu.Lock()
+		cks.schemaCache.closeSession()
+		cks.schemaCache.mu.Unlock()
+	}
+	if cks.CloseSessionFunc != nil {
+		cks.CloseSessionFunc()
+	}
+	cks.Session, cks.CloseSessionFunc = nil, nil
 }
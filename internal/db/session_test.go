@@ -0,0 +1,33 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParkedSessionsAreCapped(t *testing.T) {
+	cks := &CQLKeyspaceSession{}
+	closed := make([]string, 0)
+	for i := 0; i < maxParkedSessions+2; i++ {
+		keyspace := fmt.Sprintf("ks%d", i)
+		cks.park(&parkedSession{keyspace: keyspace, closeFunc: func() { closed = append(closed, keyspace) }})
+	}
+	if len(cks.parked) != maxParkedSessions {
+		t.Errorf("Expected %d parked sessions, got: %d", maxParkedSessions, len(cks.parked))
+	}
+	if len(closed) != 2 || closed[0] != "ks0" || closed[1] != "ks1" {
+		t.Errorf("Expected the least recently used sessions to be closed, got: %v", closed)
+	}
+
+	if p := cks.unpark("ks3"); p == nil || p.keyspace != "ks3" {
+		t.Fatalf("Expected the parked session of ks3, got: %v", p)
+	}
+	if p := cks.unpark("ks0"); p != nil {
+		t.Errorf("Expected no session for a closed keyspace, got: %v", p)
+	}
+
+	cks.Close()
+	if len(closed) != maxParkedSessions+1 || cks.parked != nil {
+		t.Errorf("Expected Close to close the parked sessions, got closed: %v", closed)
+	}
+}
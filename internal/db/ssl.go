@@ -0,0 +1,104 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gocql/gocql"
+)
+
+// SSLConfig describes the TLS settings of the client connections
+type SSLConfig struct {
+	Enabled bool
+	// CAFile is the PEM file with the CA certificates the server certificate
+	// is verified against, the system roots are used when empty
+	CAFile string
+	// CertFile and KeyFile hold the client certificate for mutual TLS
+	CertFile string
+	KeyFile  string
+	// NoVerify skips the verification of the server certificate and host name
+	NoVerify bool
+}
+
+// tlsConfig loads the certificates and builds the TLS configuration
+func (c *SSLConfig) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: c.NoVerify}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA file: %v", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM encoded certificates found in CA file %s", c.CAFile)
+		}
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, fmt.Errorf("both a client certificate and key are required for client authentication")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate %s and key %s: %v", c.CertFile, c.KeyFile, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+func (c *SSLConfig) sslOptions() (*gocql.SslOptions, error) {
+	if c == nil || !c.Enabled {
+		return nil, nil
+	}
+	cfg, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &gocql.SslOptions{Config: cfg, EnableHostVerification: !c.NoVerify}, nil
+}
+
+const hostMismatch = "the server certificate does not match the host name, " +
+	"connect using a name in the certificate or skip verification with -ssl-no-verify"
+
+// tlsErrors maps fragments of handshake errors to explanations. gocql
+// flattens the errors it returns, so they can only be told apart by text.
+var tlsErrors = []struct {
+	fragment string
+	message  string
+}{
+	{"certificate signed by unknown authority",
+		"the server certificate is not signed by a trusted CA, pass it with -ssl-ca or skip verification with -ssl-no-verify"},
+	{"certificate is valid for", hostMismatch},
+	{"certificate is not valid for any names", hostMismatch},
+	{"because it doesn't contain any IP SANs", hostMismatch},
+	{"certificate has expired or is not yet valid",
+		"the server certificate has expired or is not yet valid"},
+	{"first record does not look like a TLS handshake",
+		"the server does not accept TLS connections, check that client encryption is enabled on it"},
+	{"certificate required",
+		"the server requires a client certificate, pass it with -ssl-cert and -ssl-key"},
+	{"bad certificate",
+		"the server rejected the client certificate"},
+	{"unknown certificate authority",
+		"the server does not trust the CA of the client certificate"},
+}
+
+// sslError explains a failed TLS handshake with host, other errors are
+// returned unchanged
+func sslError(err error, host string) error {
+	if err == nil {
+		return nil
+	}
+	for _, e := range tlsErrors {
+		if strings.Contains(err.Error(), e.fragment) {
+			return fmt.Errorf("TLS handshake with %s failed: %s (%v)", host, e.message, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,249 @@
+package db
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+func (c *testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		t.Fatalf("Failed to load key pair: %v", err)
+	}
+	return cert
+}
+
+// issueCert creates a certificate signed by ca, or a self signed CA when ca is nil
+func issueCert(t *testing.T, name string, ca *testCert, hosts ...string) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	parent, signer := tmpl, key
+	if ca == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	dir := t.TempDir()
+	c := &testCert{cert: cert, key: key,
+		certFile: filepath.Join(dir, name+".crt"), keyFile: filepath.Join(dir, name+".key")}
+	writePEM(t, c.certFile, "CERTIFICATE", der)
+	writePEM(t, c.keyFile, "EC PRIVATE KEY", keyDER)
+	return c
+}
+
+func writePEM(t *testing.T, file string, blockType string, der []byte) {
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write %s: %v", file, err)
+	}
+}
+
+// startStandIn listens on a local port and completes the TLS handshake with
+// every client before closing the connection. A nil config answers with
+// plain bytes like a server without client encryption.
+func startStandIn(t *testing.T, cfg *tls.Config) (string, int) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			if cfg == nil {
+				_, _ = conn.Write([]byte("\x84\x00\x00\x00\x00\x00\x00\x00\x00"))
+			} else {
+				_ = tls.Server(conn, cfg).Handshake()
+			}
+			conn.Close()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return host, p
+}
+
+// handshake connects with the client configuration and reads until the
+// stand-in closes the connection, so that alerts sent by the server after
+// a TLS 1.3 handshake are noticed
+func handshake(ssl *SSLConfig, host string, port int) error {
+	cfg, err := ssl.tlsConfig()
+	if err != nil {
+		return err
+	}
+	conn, err := tls.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)), cfg)
+	if err != nil {
+		return sslError(err, host)
+	}
+	defer conn.Close()
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) {
+		return sslError(err, host)
+	}
+	return nil
+}
+
+func TestSSLConfigFiles(t *testing.T) {
+	ca := issueCert(t, "ca", nil)
+	client := issueCert(t, "client", ca)
+
+	tests := []struct {
+		name     string
+		ssl      SSLConfig
+		expected string
+	}{
+		{name: "missing CA file", ssl: SSLConfig{CAFile: filepath.Join(t.TempDir(), "none.pem")}, expected: "unable to read CA file"},
+		{name: "CA file without certificates", ssl: SSLConfig{CAFile: client.keyFile}, expected: "no PEM encoded certificates"},
+		{name: "certificate without key", ssl: SSLConfig{CertFile: client.certFile}, expected: "both a client certificate and key"},
+		{name: "mismatched key", ssl: SSLConfig{CertFile: client.certFile, KeyFile: ca.keyFile}, expected: "unable to load client certificate"},
+		{name: "valid", ssl: SSLConfig{CAFile: ca.certFile, CertFile: client.certFile, KeyFile: client.keyFile}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.ssl.tlsConfig()
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got: %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestSSLHandshake(t *testing.T) {
+	ca := issueCert(t, "ca", nil)
+	server := issueCert(t, "server", ca, "127.0.0.1")
+	otherServer := issueCert(t, "other", ca, "cassandra.example.com")
+	client := issueCert(t, "client", ca)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	tests := []struct {
+		name     string
+		server   *tls.Config
+		ssl      SSLConfig
+		expected string
+	}{
+		{
+			name:   "trusted CA",
+			server: &tls.Config{Certificates: []tls.Certificate{server.tlsCertificate(t)}},
+			ssl:    SSLConfig{CAFile: ca.certFile},
+		},
+		{
+			name:     "unknown CA",
+			server:   &tls.Config{Certificates: []tls.Certificate{server.tlsCertificate(t)}},
+			ssl:      SSLConfig{},
+			expected: "not signed by a trusted CA",
+		},
+		{
+			name:     "host name mismatch",
+			server:   &tls.Config{Certificates: []tls.Certificate{otherServer.tlsCertificate(t)}},
+			ssl:      SSLConfig{CAFile: ca.certFile},
+			expected: "does not match the host name",
+		},
+		{
+			name:   "host name mismatch without verification",
+			server: &tls.Config{Certificates: []tls.Certificate{otherServer.tlsCertificate(t)}},
+			ssl:    SSLConfig{NoVerify: true},
+		},
+		{
+			name: "missing client certificate",
+			server: &tls.Config{Certificates: []tls.Certificate{server.tlsCertificate(t)},
+				ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs},
+			ssl:      SSLConfig{CAFile: ca.certFile},
+			expected: "requires a client certificate",
+		},
+		{
+			name: "client certificate",
+			server: &tls.Config{Certificates: []tls.Certificate{server.tlsCertificate(t)},
+				ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs},
+			ssl: SSLConfig{CAFile: ca.certFile, CertFile: client.certFile, KeyFile: client.keyFile},
+		},
+		{
+			name:     "server without TLS",
+			ssl:      SSLConfig{NoVerify: true},
+			expected: "does not accept TLS connections",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port := startStandIn(t, tt.server)
+			err := handshake(&tt.ssl, host, port)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got: %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestNewSessionSSLError(t *testing.T) {
+	ca := issueCert(t, "ca", nil)
+	server := issueCert(t, "server", ca, "127.0.0.1")
+	host, port := startStandIn(t, &tls.Config{Certificates: []tls.Certificate{server.tlsCertificate(t)}})
+
+	_, _, _, err := NewSession(host, port, "", "", "", 0, &SSLConfig{Enabled: true}, RoutingConfig{})
+	if err == nil || !strings.Contains(err.Error(), "not signed by a trusted CA") {
+		t.Errorf("Expected unknown CA error, got: %v", err)
+	}
+}
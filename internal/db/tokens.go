@@ -0,0 +1,62 @@
+package db
+
+import (
+	"math/big"
+	"strings"
+)
+
+// TokenRange is a part of the token ring, Start is exclusive and End
+// inclusive as in token(pk) > Start AND token(pk) <= End
+type TokenRange struct {
+	Start string
+	End   string
+}
+
+// tokenBounds are the lowest and highest tokens of the partitioners whose
+// tokens are numbers
+var tokenBounds = map[string][2]*big.Int{
+	"Murmur3Partitioner": {
+		new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 63)),
+		new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 63), big.NewInt(1)),
+	},
+	"RandomPartitioner": {
+		big.NewInt(-1),
+		new(big.Int).Lsh(big.NewInt(1), 127),
+	},
+}
+
+// SplitTokenRing divides the token ring of partitioner into n ranges of
+// about the same size. It returns nil for partitioners that order keys
+// by their bytes, whose rings can not be split without sampling the data.
+func SplitTokenRing(partitioner string, n int) []TokenRange {
+	bounds, ok := tokenBounds[partitioner[strings.LastIndex(partitioner, ".")+1:]]
+	if !ok {
+		return nil
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	min, max := bounds[0], bounds[1]
+	width := new(big.Int).Sub(max, min)
+	ranges := make([]TokenRange, n)
+	start := min
+	for i := range ranges {
+		end := max
+		if i < n-1 {
+			end = new(big.Int).Mul(width, big.NewInt(int64(i+1)))
+			end.Div(end, big.NewInt(int64(n)))
+			end.Add(end, min)
+		}
+		ranges[i] = TokenRange{Start: start.String(), End: end.String()}
+		start = end
+	}
+	return ranges
+}
+
+// FetchPartitioner returns the class name of the partitioner of the cluster
+func (cks *CQLKeyspaceSession) FetchPartitioner() (string, error) {
+	var partitioner string
+	err := cks.Session.Query("SELECT partitioner FROM system.local").Scan(&partitioner)
+	return partitioner, err
+}
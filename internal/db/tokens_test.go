@@ -0,0 +1,32 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestSplitTokenRing(t *testing.T) {
+	ranges := SplitTokenRing("org.apache.cassandra.dht.Murmur3Partitioner", 4)
+	expected := []TokenRange{
+		{Start: "-9223372036854775808", End: "-4611686018427387905"},
+		{Start: "-4611686018427387905", End: "-1"},
+		{Start: "-1", End: "4611686018427387903"},
+		{Start: "4611686018427387903", End: "9223372036854775807"},
+	}
+	if len(ranges) != len(expected) {
+		t.Fatalf("Expected %d ranges, got: %v", len(expected), ranges)
+	}
+	for i := range expected {
+		if ranges[i] != expected[i] {
+			t.Errorf("Expected range %d to be %v, got: %v", i, expected[i], ranges[i])
+		}
+	}
+
+	ranges = SplitTokenRing("org.apache.cassandra.dht.RandomPartitioner", 1)
+	if len(ranges) != 1 || ranges[0].Start != "-1" || ranges[0].End != "170141183460469231731687303715884105728" {
+		t.Errorf("Expected the whole RandomPartitioner ring, got: %v", ranges)
+	}
+
+	if ranges := SplitTokenRing("org.apache.cassandra.dht.ByteOrderedPartitioner", 4); ranges != nil {
+		t.Errorf("Expected no ranges for ByteOrderedPartitioner, got: %v", ranges)
+	}
+}
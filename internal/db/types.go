@@ -0,0 +1,164 @@
+package db
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gocql/gocql"
+)
+
+const marshalPackage = "org.apache.cassandra.db.marshal."
+
+// VectorType is the Cassandra 5 vector<type, n> type. gocql does not know
+// it and reports it as a custom type with the marshal class as its name.
+type VectorType struct {
+	gocql.NativeType
+	Elem       gocql.TypeInfo
+	Dimensions int
+}
+
+var marshalNativeTypes = map[string]gocql.Type{
+	"AsciiType":         gocql.TypeAscii,
+	"LongType":          gocql.TypeBigInt,
+	"BytesType":         gocql.TypeBlob,
+	"BooleanType":       gocql.TypeBoolean,
+	"CounterColumnType": gocql.TypeCounter,
+	"DecimalType":       gocql.TypeDecimal,
+	"DoubleType":        gocql.TypeDouble,
+	"FloatType":         gocql.TypeFloat,
+	"Int32Type":         gocql.TypeInt,
+	"ShortType":         gocql.TypeSmallInt,
+	"ByteType":          gocql.TypeTinyInt,
+	"TimeType":          gocql.TypeTime,
+	"SimpleDateType":    gocql.TypeDate,
+	"TimestampType":     gocql.TypeTimestamp,
+	"DateType":          gocql.TypeTimestamp,
+	"UUIDType":          gocql.TypeUUID,
+	"LexicalUUIDType":   gocql.TypeUUID,
+	"UTF8Type":          gocql.TypeVarchar,
+	"IntegerType":       gocql.TypeVarint,
+	"TimeUUIDType":      gocql.TypeTimeUUID,
+	"InetAddressType":   gocql.TypeInet,
+	"DurationType":      gocql.TypeDuration,
+}
+
+// fixedLengths are the serialized sizes of the types Cassandra writes
+// without a length prefix inside a vector
+var fixedLengths = map[gocql.Type]int{
+	gocql.TypeBoolean:   1,
+	gocql.TypeInt:       4,
+	gocql.TypeFloat:     4,
+	gocql.TypeBigInt:    8,
+	gocql.TypeDouble:    8,
+	gocql.TypeTimestamp: 8,
+	gocql.TypeUUID:      16,
+	gocql.TypeTimeUUID:  16,
+}
+
+// FixedLength returns the serialized size of a type that is written without
+// a length prefix in vectors, or 0 for variable length types
+func FixedLength(info gocql.TypeInfo) int {
+	if info.Type() == gocql.TypeCustom {
+		return 0
+	}
+	return fixedLengths[info.Type()]
+}
+
+// ResolveCustomType returns the type described by the marshal class of a
+// custom type, so that types newer than the driver can still be decoded.
+// Types that cannot be resolved are returned unchanged.
+func ResolveCustomType(info gocql.TypeInfo) gocql.TypeInfo {
+	if info.Type() != gocql.TypeCustom || !strings.HasPrefix(info.Custom(), marshalPackage) {
+		return info
+	}
+	if t := parseMarshalType(info.Custom(), info.Version()); t != nil {
+		return t
+	}
+	return info
+}
+
+// IsVectorColumn reports whether a column holds a vector
+func IsVectorColumn(col gocql.ColumnInfo) bool {
+	_, ok := ResolveCustomType(col.TypeInfo).(VectorType)
+	return ok
+}
+
+// parseMarshalType parses marshal class names like
+// org.apache.cassandra.db.marshal.VectorType(org.apache.cassandra.db.marshal.FloatType,3)
+func parseMarshalType(class string, proto byte) gocql.TypeInfo {
+	class = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(class), marshalPackage))
+	name, args := class, []string(nil)
+	if i := strings.IndexByte(class, '('); i >= 0 && strings.HasSuffix(class, ")") {
+		name, args = class[:i], splitMarshalArgs(class[i+1:len(class)-1])
+	}
+
+	if t, ok := marshalNativeTypes[name]; ok && len(args) == 0 {
+		return gocql.NewNativeType(proto, t, "")
+	}
+
+	switch name {
+	case "FrozenType", "ReversedType":
+		if len(args) == 1 {
+			return parseMarshalType(args[0], proto)
+		}
+	case "ListType", "SetType":
+		if len(args) == 1 {
+			if elem := parseMarshalType(args[0], proto); elem != nil {
+				typ := gocql.TypeList
+				if name == "SetType" {
+					typ = gocql.TypeSet
+				}
+				return gocql.CollectionType{NativeType: gocql.NewNativeType(proto, typ, ""), Elem: elem}
+			}
+		}
+	case "MapType":
+		if len(args) == 2 {
+			key, elem := parseMarshalType(args[0], proto), parseMarshalType(args[1], proto)
+			if key != nil && elem != nil {
+				return gocql.CollectionType{NativeType: gocql.NewNativeType(proto, gocql.TypeMap, ""), Key: key, Elem: elem}
+			}
+		}
+	case "TupleType":
+		elems := make([]gocql.TypeInfo, len(args))
+		for i, arg := range args {
+			if elems[i] = parseMarshalType(arg, proto); elems[i] == nil {
+				return nil
+			}
+		}
+		return gocql.TupleTypeInfo{NativeType: gocql.NewNativeType(proto, gocql.TypeTuple, ""), Elems: elems}
+	case "VectorType":
+		if len(args) == 2 {
+			elem := parseMarshalType(args[0], proto)
+			dimensions, err := strconv.Atoi(strings.TrimSpace(args[1]))
+			if elem != nil && err == nil {
+				return VectorType{
+					NativeType: gocql.NewNativeType(proto, gocql.TypeCustom, marshalPackage+class),
+					Elem:       elem,
+					Dimensions: dimensions,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// splitMarshalArgs splits the arguments of a marshal class at the commas
+// that are not nested in parentheses
+func splitMarshalArgs(s string) []string {
+	args := make([]string, 0)
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(args, s[start:])
+}
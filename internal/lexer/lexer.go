@@ -0,0 +1,118 @@
+package lexer
+
+import (
+	"strings"
+)
+
+// Kind is the kind of a token
+type Kind int
+
+const (
+	// Word is a keyword, an unquoted identifier or a number
+	Word Kind = iota
+	// String is a single quoted string literal, '' escapes a quote
+	String
+	// QuotedIdentifier is a double quoted identifier, "" escapes a quote
+	QuotedIdentifier
+	// DollarString is a $$ quoted string, used for function bodies
+	DollarString
+	// Symbol is any other single character, like ; ( or =
+	Symbol
+	// Comment is a --, // or /* */ comment
+	Comment
+	// Space is a run of whitespace
+	Space
+)
+
+// Token is a piece of CQL input
+type Token struct {
+	Kind Kind
+	Text string
+	// Complete is false for a literal or comment cut off by the end of input
+	Complete bool
+}
+
+// Significant reports whether the token is part of the statement, which
+// comments and whitespace are not
+func (t Token) Significant() bool {
+	return t.Kind != Comment && t.Kind != Space
+}
+
+// Is reports whether the token is the keyword kw, ignoring case
+func (t Token) Is(kw string) bool {
+	return t.Kind == Word && strings.EqualFold(t.Text, kw)
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+// Tokenize splits input into tokens. The tokens cover the whole input, so
+// joining their texts gives it back.
+func Tokenize(input string) []Token {
+	tokens := make([]Token, 0)
+	for i := 0; i < len(input); {
+		t := next(input[i:])
+		tokens = append(tokens, t)
+		i += len(t.Text)
+	}
+	return tokens
+}
+
+// next reads the token at the start of s
+func next(s string) Token {
+	c := s[0]
+	switch {
+	case isSpace(c):
+		n := 1
+		for n < len(s) && isSpace(s[n]) {
+			n++
+		}
+		return Token{Kind: Space, Text: s[:n], Complete: true}
+	case isWordChar(c):
+		n := 1
+		for n < len(s) && isWordChar(s[n]) {
+			n++
+		}
+		return Token{Kind: Word, Text: s[:n], Complete: true}
+	case c == '\'':
+		return quoted(s, String, '\'')
+	case c == '"':
+		return quoted(s, QuotedIdentifier, '"')
+	case strings.HasPrefix(s, "$$"):
+		if end := strings.Index(s[2:], "$$"); end >= 0 {
+			return Token{Kind: DollarString, Text: s[:end+4], Complete: true}
+		}
+		return Token{Kind: DollarString, Text: s}
+	case strings.HasPrefix(s, "--"), strings.HasPrefix(s, "//"):
+		if end := strings.IndexByte(s, '\n'); end >= 0 {
+			return Token{Kind: Comment, Text: s[:end], Complete: true}
+		}
+		return Token{Kind: Comment, Text: s, Complete: true}
+	case strings.HasPrefix(s, "/*"):
+		if end := strings.Index(s[2:], "*/"); end >= 0 {
+			return Token{Kind: Comment, Text: s[:end+4], Complete: true}
+		}
+		return Token{Kind: Comment, Text: s}
+	}
+	return Token{Kind: Symbol, Text: s[:1], Complete: true}
+}
+
+// quoted reads a literal enclosed in quote, where a doubled quote escapes it
+func quoted(s string, kind Kind, quote byte) Token {
+	for n := 1; n < len(s); n++ {
+		if s[n] != quote {
+			continue
+		}
+		if n+1 < len(s) && s[n+1] == quote {
+			n++
+			continue
+		}
+		return Token{Kind: kind, Text: s[:n+1], Complete: true}
+	}
+	return Token{Kind: kind, Text: s}
+}
@@ -0,0 +1,152 @@
+package lexer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	input := `SELECT "My""Col", 'it''s' FROM t -- trailing; comment` + "\n" + `/* block; */ $$ return 'x;'; $$;`
+	tokens := Tokenize(input)
+
+	var joined strings.Builder
+	var significant []string
+	for _, tok := range tokens {
+		joined.WriteString(tok.Text)
+		if tok.Significant() {
+			significant = append(significant, tok.Text)
+		}
+	}
+	if joined.String() != input {
+		t.Errorf("Expected tokens to cover the input, got: %q", joined.String())
+	}
+
+	expected := []string{"SELECT", `"My""Col"`, ",", `'it''s'`, "FROM", "t", "$$ return 'x;'; $$", ";"}
+	if !reflect.DeepEqual(significant, expected) {
+		t.Errorf("Expected %q, got: %q", expected, significant)
+	}
+}
+
+func TestTokenizeUnterminated(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  Kind
+	}{
+		{input: "select 'abc", kind: String},
+		{input: `select "abc`, kind: QuotedIdentifier},
+		{input: "create function f() as $$ return", kind: DollarString},
+		{input: "select /* abc", kind: Comment},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens := Tokenize(tt.input)
+			last := tokens[len(tokens)-1]
+			if last.Kind != tt.kind || last.Complete {
+				t.Errorf("Expected incomplete token of kind %d, got: %+v", tt.kind, last)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		rest     string
+	}{
+		{
+			name:     "semicolon in string",
+			input:    "insert into t (k, v) values (1, 'a;b'); select * from t;",
+			expected: []string{"insert into t (k, v) values (1, 'a;b');", "select * from t;"},
+		},
+		{
+			name:     "escaped quote",
+			input:    "insert into t (v) values ('it''s; fine');",
+			expected: []string{"insert into t (v) values ('it''s; fine');"},
+		},
+		{
+			name:     "quoted identifier",
+			input:    `select "a;b" from t;`,
+			expected: []string{`select "a;b" from t;`},
+		},
+		{
+			name: "function body",
+			input: "CREATE FUNCTION f(a int) RETURNS NULL ON NULL INPUT RETURNS int LANGUAGE java " +
+				"AS $$ int b = a; return b; $$;",
+			expected: []string{"CREATE FUNCTION f(a int) RETURNS NULL ON NULL INPUT RETURNS int LANGUAGE java " +
+				"AS $$ int b = a; return b; $$;"},
+		},
+		{
+			name:     "comments",
+			input:    "-- create; the table\nselect * // from; t\nfrom t /* ; */ where k = 1;",
+			expected: []string{"select *  \nfrom t   where k = 1;"},
+		},
+		{
+			name:     "only comments",
+			input:    "-- nothing here;\n/* ; */;",
+			expected: nil,
+		},
+		{
+			name: "batch",
+			input: "BEGIN UNLOGGED BATCH\n  insert into t (k) values (1);\n  insert into t (k) values (2);\nAPPLY BATCH;\n" +
+				"select * from t;",
+			expected: []string{
+				"BEGIN UNLOGGED BATCH\n  insert into t (k) values (1);\n  insert into t (k) values (2);\nAPPLY BATCH;",
+				"select * from t;",
+			},
+		},
+		{
+			name:     "unterminated last statement",
+			input:    "select * from a; select * from b",
+			expected: []string{"select * from a;"},
+			rest:     " select * from b",
+		},
+		{
+			name:     "unterminated string",
+			input:    "select * from a; insert into t (v) values ('a;\nb",
+			expected: []string{"select * from a;"},
+			rest:     " insert into t (v) values ('a;\nb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts, rest := Split(tt.input)
+			if !reflect.DeepEqual(stmts, tt.expected) {
+				t.Errorf("Expected %q, got: %q", tt.expected, stmts)
+			}
+			if rest != tt.rest {
+				t.Errorf("Expected rest %q, got: %q", tt.rest, rest)
+			}
+		})
+	}
+}
+
+func TestPending(t *testing.T) {
+	tests := []struct {
+		rest     string
+		expected bool
+	}{
+		{rest: "", expected: false},
+		{rest: "  \n", expected: false},
+		{rest: "-- just a comment\n", expected: false},
+		{rest: "select * from t", expected: true},
+		{rest: "/* open comment", expected: true},
+		{rest: "BEGIN BATCH insert into t (k) values (1);", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rest, func(t *testing.T) {
+			if got := Pending(tt.rest); got != tt.expected {
+				t.Errorf("Expected %v, got: %v", tt.expected, got)
+			}
+		})
+	}
+
+	if got := Remainder(" select * -- all\nfrom t "); got != "select *  \nfrom t" {
+		t.Errorf("Expected statement without comment, got: %q", got)
+	}
+}
@@ -0,0 +1,87 @@
+package lexer
+
+import (
+	"strings"
+)
+
+// Split splits input into the statements terminated by a semicolon.
+// Semicolons in literals, comments and BEGIN BATCH ... APPLY BATCH blocks do
+// not end a statement. Comments are removed from the statements, which keep
+// their terminating semicolon. rest is the input following the last
+// terminated statement, including any unterminated literal or comment.
+func Split(input string) (stmts []string, rest string) {
+	var stmt strings.Builder
+	var b batch
+	start := 0
+	pos := 0
+	for _, t := range Tokenize(input) {
+		pos += len(t.Text)
+		switch {
+		case t.Kind == Comment:
+			// keep the tokens on both sides apart
+			stmt.WriteByte(' ')
+			continue
+		case t.Significant() && t.Text != ";":
+			b.add(t)
+		}
+		stmt.WriteString(t.Text)
+
+		if t.Kind == Symbol && t.Text == ";" && !b.open() {
+			if s := strings.TrimSpace(stmt.String()); s != ";" {
+				stmts = append(stmts, s)
+			}
+			stmt.Reset()
+			b = batch{}
+			start = pos
+		}
+	}
+	return stmts, input[start:]
+}
+
+// Remainder returns the statement left in the rest of Split, without its
+// comments, or an empty string when there is none
+func Remainder(rest string) string {
+	var stmt strings.Builder
+	for _, t := range Tokenize(rest) {
+		if t.Kind == Comment {
+			stmt.WriteByte(' ')
+			continue
+		}
+		stmt.WriteString(t.Text)
+	}
+	return strings.TrimSpace(stmt.String())
+}
+
+// Pending reports whether the rest of Split holds the start of a statement
+// or an unterminated comment, so that interactive input waits for more lines
+func Pending(rest string) bool {
+	tokens := Tokenize(rest)
+	if len(tokens) > 0 && !tokens[len(tokens)-1].Complete {
+		return true
+	}
+	return Remainder(rest) != ""
+}
+
+// batch follows the words of a statement to tell whether it is a batch
+// that has not been applied yet
+type batch struct {
+	words   int
+	begun   bool
+	applied bool
+	prev    Token
+}
+
+func (b *batch) add(t Token) {
+	if b.words == 0 && t.Is("begin") {
+		b.begun = true
+	}
+	if b.begun && b.prev.Is("apply") && t.Is("batch") {
+		b.applied = true
+	}
+	b.words++
+	b.prev = t
+}
+
+func (b *batch) open() bool {
+	return b.begun && !b.applied
+}
@@ -0,0 +1,361 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Column describes a column of query results
+type Column struct {
+	Name string
+	// HeaderColor and ValueColor are used by the table format
+	HeaderColor func(a ...interface{}) string
+	ValueColor  func(a ...interface{}) string
+}
+
+// Value is a value of query results
+type Value struct {
+	// Text is the value as cqlsh displays it
+	Text string
+	// JSON is the value as encoded by encoding/json, nil for null
+	JSON interface{}
+}
+
+// Formatter writes query results page by page
+type Formatter interface {
+	// WritePage writes a page of rows, first is the number of rows written
+	// before it. It is called at least once, with no rows for empty results,
+	// and pages without rows may come before the first row.
+	WritePage(cols []Column, rows [][]Value, first int) error
+	// Finish completes the output after the last page, total is the number
+	// of rows written
+	Finish(total int) error
+}
+
+// DefaultFormat is the format of the interactive shell
+const DefaultFormat = "table"
+
+var formats = map[string]func(w io.Writer, expand bool) Formatter{
+	"table": func(w io.Writer, expand bool) Formatter {
+		return &tableFormatter{w: w, expand: expand}
+	},
+	"csv": func(w io.Writer, _ bool) Formatter {
+		return NewCSVFormatter(w, CSVOptions{Delimiter: ',', Header: true})
+	},
+	"tsv": func(w io.Writer, _ bool) Formatter {
+		return NewCSVFormatter(w, CSVOptions{Delimiter: '\t', Header: true})
+	},
+	"json": func(w io.Writer, _ bool) Formatter {
+		return &jsonFormatter{w: w}
+	},
+	"ndjson": func(w io.Writer, _ bool) Formatter {
+		return &jsonFormatter{w: w, lines: true}
+	},
+	"markdown": func(w io.Writer, _ bool) Formatter {
+		return &markdownFormatter{w: w}
+	},
+}
+
+// Formats returns the names of the output formats
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewFormatter creates the formatter of the named format writing to w,
+// expand prints the rows of the table format as vertical blocks
+func NewFormatter(name string, w io.Writer, expand bool) (Formatter, error) {
+	if name == "" {
+		name = DefaultFormat
+	}
+	f, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %s, use one of %s", name, strings.Join(Formats(), ", "))
+	}
+	return f(w, expand), nil
+}
+
+// Paused tells a formatter that the output pauses after every page. The
+// table format then prints every page with a header of its own, otherwise
+// it prints the header once.
+func Paused(f Formatter) {
+	if t, ok := f.(*tableFormatter); ok {
+		t.paused = true
+	}
+}
+
+// tableFormatter draws the ASCII table of cqlsh. The columns of a paused
+// page are as wide as its widest value, without pauses they keep the width
+// of the first page and only grow for wider values.
+type tableFormatter struct {
+	w       io.Writer
+	expand  bool
+	paused  bool
+	started bool
+	// widths are the column widths of the rows written so far
+	widths []int
+}
+
+func (f *tableFormatter) WritePage(cols []Column, rows [][]Value, first int) error {
+	started := f.started
+	f.started = true
+	if !started {
+		fmt.Fprintln(f.w, "")
+	}
+	if f.expand {
+		f.writeExpanded(cols, rows, first)
+		return nil
+	}
+	// the header is printed for the first page even without rows
+	if len(rows) == 0 && started {
+		return nil
+	}
+
+	header := f.paused || f.widths == nil
+	cellWidths := f.widths
+	if header {
+		cellWidths = make([]int, len(cols))
+		for colIdx, col := range cols {
+			cellWidths[colIdx] = len(col.Name)
+		}
+	}
+	for colIdx := range cols {
+		for _, row := range rows {
+			if len(row[colIdx].Text) > cellWidths[colIdx] {
+				cellWidths[colIdx] = len(row[colIdx].Text)
+			}
+		}
+	}
+	f.widths = cellWidths
+	if !header {
+		f.writeRows(cols, rows, cellWidths)
+		return nil
+	}
+
+	for colIdx, col := range cols {
+		FprintColoredColumnVal(f.w, cellWidths[colIdx], col.Name, col.HeaderColor)
+	}
+	fmt.Fprintf(f.w, "\n")
+
+	for colIdx := range cols {
+		FprintHeaderSeparator(f.w, cellWidths[colIdx])
+	}
+	fmt.Fprintf(f.w, "\n")
+	f.writeRows(cols, rows, cellWidths)
+	return nil
+}
+
+// writeRows prints the rows below the header
+func (f *tableFormatter) writeRows(cols []Column, rows [][]Value, cellWidths []int) {
+
+	for _, row := range rows {
+		for colIdx, col := range cols {
+			FprintColoredColumnVal(f.w, cellWidths[colIdx], row[colIdx].Text, col.ValueColor)
+		}
+		fmt.Fprintf(f.w, "\n")
+	}
+}
+
+// writeExpanded prints every row as a vertical block of column and value
+// lines, numbering them from first+1
+func (f *tableFormatter) writeExpanded(cols []Column, rows [][]Value, first int) {
+	nameWidth := 0
+	for _, col := range cols {
+		if len(col.Name) > nameWidth {
+			nameWidth = len(col.Name)
+		}
+	}
+
+	for rowIdx, row := range rows {
+		valueWidth := 0
+		for _, v := range row {
+			if len(v.Text) > valueWidth {
+				valueWidth = len(v.Text)
+			}
+		}
+		if first+rowIdx > 0 {
+			fmt.Fprintf(f.w, "\n")
+		}
+		FprintRecordHeader(f.w, first+rowIdx+1, nameWidth, valueWidth)
+		for colIdx, col := range cols {
+			FprintRecordVal(f.w, nameWidth, col.Name, col.HeaderColor, row[colIdx].Text, col.ValueColor)
+		}
+	}
+}
+
+func (f *tableFormatter) Finish(total int) error {
+	if total == 1 {
+		fmt.Fprintf(f.w, "\n (%d row)\n", total)
+	} else {
+		fmt.Fprintf(f.w, "\n (%d rows)\n", total)
+	}
+	return nil
+}
+
+// CSVOptions configures a delimited formatter
+type CSVOptions struct {
+	Delimiter rune
+	// Header writes a line with the column names first
+	Header bool
+	// Null is written for null values
+	Null string
+}
+
+// NewCSVFormatter creates a formatter writing delimited values, quoted as
+// RFC 4180 describes
+func NewCSVFormatter(w io.Writer, opts CSVOptions) Formatter {
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.Delimiter
+	return &delimitedFormatter{w: cw, header: opts.Header, null: opts.Null}
+}
+
+// delimitedFormatter writes CSV or TSV
+type delimitedFormatter struct {
+	w       *csv.Writer
+	header  bool
+	null    string
+	started bool
+}
+
+func (f *delimitedFormatter) WritePage(cols []Column, rows [][]Value, first int) error {
+	if !f.started && f.header {
+		header := make([]string, len(cols))
+		for i, col := range cols {
+			header[i] = col.Name
+		}
+		if err := f.w.Write(header); err != nil {
+			return err
+		}
+	}
+	f.started = true
+	record := make([]string, len(cols))
+	for _, row := range rows {
+		for i, v := range row {
+			record[i] = v.Text
+			if v.JSON == nil {
+				record[i] = f.null
+			}
+		}
+		if err := f.w.Write(record); err != nil {
+			return err
+		}
+	}
+	f.w.Flush()
+	return f.w.Error()
+}
+
+func (f *delimitedFormatter) Finish(total int) error {
+	return nil
+}
+
+// jsonFormatter writes a JSON array of row objects, or one object per line
+type jsonFormatter struct {
+	w       io.Writer
+	lines   bool
+	started bool
+}
+
+func (f *jsonFormatter) WritePage(cols []Column, rows [][]Value, first int) error {
+	if !f.started && !f.lines {
+		if _, err := io.WriteString(f.w, "["); err != nil {
+			return err
+		}
+	}
+	f.started = true
+	for i, row := range rows {
+		obj, err := jsonObject(cols, row)
+		if err != nil {
+			return err
+		}
+		sep := "\n"
+		if f.lines {
+			obj, sep = append(obj, '\n'), ""
+		} else if first+i > 0 {
+			sep = ",\n"
+		}
+		if _, err := io.WriteString(f.w, sep); err != nil {
+			return err
+		}
+		if _, err := f.w.Write(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *jsonFormatter) Finish(total int) error {
+	if f.lines {
+		return nil
+	}
+	closing := "\n]\n"
+	if total == 0 {
+		closing = "]\n"
+	}
+	_, err := io.WriteString(f.w, closing)
+	return err
+}
+
+// jsonObject encodes a row as an object with the keys in column order
+func jsonObject(cols []Column, row []Value) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	for i, col := range cols {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(col.Name); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteByte(':')
+		if err := enc.Encode(row[i].JSON); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// markdownFormatter writes a GitHub flavored Markdown table
+type markdownFormatter struct {
+	w       io.Writer
+	started bool
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+func (f *markdownFormatter) WritePage(cols []Column, rows [][]Value, first int) error {
+	var b strings.Builder
+	if !f.started {
+		for _, col := range cols {
+			b.WriteString("| " + markdownEscaper.Replace(col.Name) + " ")
+		}
+		b.WriteString("|\n")
+		b.WriteString(strings.Repeat("| --- ", len(cols)) + "|\n")
+	}
+	f.started = true
+	for _, row := range rows {
+		for _, v := range row {
+			b.WriteString("| " + markdownEscaper.Replace(v.Text) + " ")
+		}
+		b.WriteString("|\n")
+	}
+	_, err := io.WriteString(f.w, b.String())
+	return err
+}
+
+func (f *markdownFormatter) Finish(total int) error {
+	return nil
+}
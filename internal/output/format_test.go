@@ -0,0 +1,157 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+var (
+	testCols = []Column{
+		{Name: "id", HeaderColor: Red, ValueColor: Green},
+		{Name: "name", HeaderColor: Magenta, ValueColor: Yellow},
+	}
+	testRows = [][]Value{
+		{{Text: "1", JSON: 1}, {Text: `say "hi", | bye`, JSON: `say "hi", | bye`}},
+		{{Text: "2", JSON: 2}, {Text: "null", JSON: nil}},
+	}
+)
+
+func writeFormat(t *testing.T, name string, pages ...[][]Value) string {
+	t.Helper()
+	var buf bytes.Buffer
+	f, err := NewFormatter(name, &buf, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	total := 0
+	for _, rows := range pages {
+		if err := f.WritePage(testCols, rows, total); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		total += len(rows)
+	}
+	if err := f.Finish(total); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	return buf.String()
+}
+
+func TestFormats(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	tests := []struct {
+		format   string
+		pages    [][][]Value
+		expected string
+	}{
+		{
+			format: "csv",
+			pages:  [][][]Value{testRows[:1], testRows[1:]},
+			expected: "id,name\n" +
+				"1,\"say \"\"hi\"\", | bye\"\n" +
+				"2,\n",
+		},
+		{
+			format:   "TSV",
+			pages:    [][][]Value{testRows},
+			expected: "id\tname\n1\t\"say \"\"hi\"\", | bye\"\n2\t\n",
+		},
+		{
+			format: "json",
+			pages:  [][][]Value{testRows[:1], testRows[1:]},
+			expected: "[\n" +
+				`{"id":1,"name":"say \"hi\", | bye"},` + "\n" +
+				`{"id":2,"name":null}` + "\n]\n",
+		},
+		{
+			format:   "json",
+			pages:    [][][]Value{{}},
+			expected: "[]\n",
+		},
+		{
+			format:   "json",
+			pages:    [][][]Value{{}, testRows[1:]},
+			expected: "[\n" + `{"id":2,"name":null}` + "\n]\n",
+		},
+		{
+			format: "ndjson",
+			pages:  [][][]Value{testRows},
+			expected: `{"id":1,"name":"say \"hi\", | bye"}` + "\n" +
+				`{"id":2,"name":null}` + "\n",
+		},
+		{
+			format: "markdown",
+			pages:  [][][]Value{testRows},
+			expected: "| id | name |\n" +
+				"| --- | --- |\n" +
+				"| 1 | say \"hi\", \\| bye |\n" +
+				"| 2 | null |\n",
+		},
+		{
+			format: "",
+			pages:  [][][]Value{testRows[:1]},
+			expected: "\n" +
+				"| id |            name \n" +
+				"+----+-----------------\n" +
+				"|  1 | say \"hi\", | bye \n" +
+				"\n (1 row)\n",
+		},
+		{
+			format: "table",
+			pages:  [][][]Value{testRows[1:], testRows[:1]},
+			expected: "\n" +
+				"| id | name \n" +
+				"+----+------\n" +
+				"|  2 | null \n" +
+				"|  1 | say \"hi\", | bye \n" +
+				"\n (2 rows)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := writeFormat(t, tt.format, tt.pages...); got != tt.expected {
+			t.Errorf("Unexpected %s output:\n%q\nexpected:\n%q", tt.format, got, tt.expected)
+		}
+	}
+}
+
+func TestPausedTableHeaders(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	var buf bytes.Buffer
+	f, _ := NewFormatter("table", &buf, false)
+	Paused(f)
+	f.WritePage(testCols, testRows[1:], 0)
+	f.WritePage(testCols, testRows[:1], 1)
+	if n := strings.Count(buf.String(), "| id |"); n != 2 {
+		t.Errorf("Expected a header for every paused page, got %d in:\n%s", n, buf.String())
+	}
+}
+
+func TestCSVFormatterOptions(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewCSVFormatter(&buf, CSVOptions{Delimiter: ';', Null: "NULL"})
+	for _, rows := range [][][]Value{{}, testRows} {
+		if err := f.WritePage(testCols, rows, 0); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	expected := "1;\"say \"\"hi\"\", | bye\"\n2;NULL\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("Expected %q, got: %q", expected, got)
+	}
+}
+
+func TestNewFormatterUnknown(t *testing.T) {
+	if _, err := NewFormatter("xml", nil, false); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
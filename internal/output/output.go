@@ -2,7 +2,10 @@ package output
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 )
@@ -14,6 +17,13 @@ var (
 	Yellow  = color.New(color.FgYellow).SprintFunc()
 	Blue    = color.New(color.FgBlue).SprintFunc()
 
+	// Location is the time zone timestamps are displayed in
+	Location = time.UTC
+
+	// MaxVectorElements limits the elements of a vector value that are
+	// displayed, 0 displays all of them
+	MaxVectorElements = 0
+
 	colors = map[color.Attribute]func(a ...interface{}) string{
 		color.FgRed:     Red,
 		color.FgMagenta: Magenta,
@@ -32,15 +42,39 @@ func PrintError(err string) {
 }
 
 func PrintColoredColumnVal(width int, val string, f func(a ...interface{}) string) {
+	FprintColoredColumnVal(os.Stdout, width, val, f)
+}
+
+func FprintColoredColumnVal(w io.Writer, width int, val string, f func(a ...interface{}) string) {
+	var addSpaceColor = 0
+	if !color.NoColor {
+		addSpaceColor = 9
+	}
+	fmt.Fprintf(w, fmt.Sprintf("| %%%ds ", width+addSpaceColor), f(val))
+}
+
+// FprintRecordHeader starts an expanded row with its number and a separator
+// sized for the column names and values
+func FprintRecordHeader(w io.Writer, row int, nameWidth int, valueWidth int) {
+	fmt.Fprintf(w, "@ Row %d\n", row)
+	fmt.Fprintf(w, "%s+%s\n", strings.Repeat("-", nameWidth+2), strings.Repeat("-", valueWidth+2))
+}
+
+// FprintRecordVal prints a column of an expanded row
+func FprintRecordVal(w io.Writer, nameWidth int, name string, nameColor func(a ...interface{}) string, val string, valColor func(a ...interface{}) string) {
 	var addSpaceColor = 0
 	if !color.NoColor {
 		addSpaceColor = 9
 	}
-	fmt.Printf(fmt.Sprintf("| %%%ds ", width+addSpaceColor), f(val))
+	fmt.Fprintf(w, fmt.Sprintf(" %%-%ds | %%s\n", nameWidth+addSpaceColor), nameColor(name), valColor(val))
 }
 
 func PrintHeaderSeparator(width int) {
-	fmt.Printf(fmt.Sprintf("+%%%ds", width+2), strings.Repeat("-", width+2))
+	FprintHeaderSeparator(os.Stdout, width)
+}
+
+func FprintHeaderSeparator(w io.Writer, width int) {
+	fmt.Fprintf(w, fmt.Sprintf("+%%%ds", width+2), strings.Repeat("-", width+2))
 }
 
 func Colorful() bool {
@@ -8,74 +8,24 @@ import (
 
 	"github.com/chzyer/readline"
 	"github.com/npenkov/gcqlsh/internal/action"
+	"github.com/npenkov/gcqlsh/internal/complete"
 	"github.com/npenkov/gcqlsh/internal/db"
+	"github.com/npenkov/gcqlsh/internal/lexer"
+	"github.com/npenkov/gcqlsh/internal/output"
 )
 
 const ProgramPromptPrefix = "gcqlsh"
 
 func RunInteractiveSession(cks *db.CQLKeyspaceSession) error {
-	var completer = readline.NewPrefixCompleter(
-		readline.PcItem("use",
-			readline.PcItemDynamic(action.ListKeyspaces(cks)),
-		),
-		readline.PcItem("select",
-			readline.PcItem("*",
-				readline.PcItem("from",
-					readline.PcItemDynamic(action.ListTables(cks)),
-				),
-			),
-		),
-		readline.PcItem("insert",
-			readline.PcItem("into",
-				readline.PcItemDynamic(action.ListTables(cks)),
-			),
-		),
-		readline.PcItem("delete",
-			readline.PcItem("from",
-				readline.PcItemDynamic(action.ListTables(cks),
-					readline.PcItem(";"),
-					readline.PcItemDynamic(action.ListColumns(cks, "delete from"),
-						readline.PcItem("="),
-					),
-				),
-			),
-		),
-		readline.PcItem("update",
-			readline.PcItemDynamic(action.ListTables(cks),
-				readline.PcItem("set",
-					readline.PcItemDynamic(action.ListColumns(cks, "update"),
-						readline.PcItem("="),
-					),
-				),
-			),
-		),
-		readline.PcItem("desc",
-			readline.PcItem("keyspaces",
-				readline.PcItem(";"),
-			),
-			readline.PcItem("keyspace",
-				readline.PcItemDynamic(action.ListKeyspaces(cks),
-					readline.PcItem(";"),
-				),
-			),
-			readline.PcItem("tables",
-				readline.PcItem(";"),
-			),
-			readline.PcItem("table",
-				readline.PcItemDynamic(action.ListTables(cks),
-					readline.PcItem(";"),
-				),
-			),
-		),
-		readline.PcItem("tracing",
-			readline.PcItem("on",
-				readline.PcItem(";"),
-			),
-			readline.PcItem("off",
-				readline.PcItem(";"),
-			),
-		),
-	)
+	// input holds the lines of a statement that is not terminated yet
+	var input string
+	completer := &complete.Completer{
+		Source:                  action.CompletionSource(cks),
+		Pending:                 func() string { return input },
+		ConsistencyLevels:       action.ConsistencyLevels,
+		SerialConsistencyLevels: action.SerialConsistencyLevels,
+		Formats:                 output.Formats(),
+	}
 	home := os.Getenv("HOME")
 	config := &readline.Config{
 		Prompt:                 fmt.Sprintf("%s:%s> ", ProgramPromptPrefix, cks.ActiveKeyspace),
@@ -92,33 +42,51 @@ func RunInteractiveSession(cks *db.CQLKeyspaceSession) error {
 	}
 	defer rl.Close()
 
-	var cmds []string
+	cks.MorePrompt = func() bool {
+		rl.SetPrompt("---MORE---")
+		line, err := rl.Readline()
+		return err == nil && !strings.EqualFold(strings.TrimSpace(line), "q")
+	}
+	cks.PasswordPrompt = func(prompt string) (string, error) {
+		password, err := rl.ReadPassword(prompt)
+		return string(password), err
+	}
+	defer func() {
+		cks.MorePrompt = nil
+		cks.PasswordPrompt = nil
+	}()
+
 	for {
 		line, err := rl.Readline()
 		if err != nil {
 			break
 		}
-		line = strings.TrimSpace(line)
-		if len(line) == 0 {
+		if input == "" && strings.TrimSpace(line) == "" {
 			continue
 		}
-		cmds = append(cmds, line)
-		if !strings.HasSuffix(line, ";") {
-			rl.SetPrompt(">>> ")
-			continue
-		}
-		cmd := strings.Join(cmds, " ")
-		cmds = cmds[:0]
-		breakLoop, _, err := action.ProcessCommand(cmd, cks)
+		input += line + "\n"
+		stmts, rest := lexer.Split(input)
+		for _, cmd := range stmts {
+			breakLoop, _, err := action.ProcessCommand(cmd, cks)
 
-		if err != nil {
-			fmt.Println(err)
+			if err != nil {
+				fmt.Println(err)
+			}
+			if breakLoop {
+				return nil
+			}
+			if entry := action.HistoryEntry(cmd); entry != "" {
+				_ = rl.SaveHistory(entry)
+			}
 		}
-		if breakLoop {
-			break
+
+		if lexer.Pending(rest) {
+			input = rest
+			rl.SetPrompt(">>> ")
+			continue
 		}
+		input = ""
 		rl.SetPrompt(fmt.Sprintf("%s:%s> ", ProgramPromptPrefix, cks.ActiveKeyspace))
-		_ = rl.SaveHistory(cmd)
 	}
 	return nil
 }
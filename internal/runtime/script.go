@@ -2,42 +2,159 @@ package runtime
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
 
 	"github.com/npenkov/gcqlsh/internal/action"
 	"github.com/npenkov/gcqlsh/internal/db"
+	"github.com/npenkov/gcqlsh/internal/lexer"
+)
+
+// Exit codes of the shell
+const (
+	ExitOK = 0
+	// ExitExecutionError is returned when no statement of a script succeeded
+	ExitExecutionError = 1
+	// ExitSyntaxError is returned when a statement was rejected as invalid CQL
+	ExitSyntaxError = 2
+	// ExitPartialFailure is returned when some statements failed and others
+	// succeeded
+	ExitPartialFailure = 3
+	// ExitConnectionError is returned when the cluster can not be reached
+	ExitConnectionError = 4
+	// ExitUsageError is returned for invalid options, configuration or script
+	// files
+	ExitUsageError = 5
 )
 
-func ProcessScriptFile(scriptFile string, cks *db.CQLKeyspaceSession, printCQL bool, failOnError bool) {
-	file, err := os.Open(scriptFile)
+// ScriptOptions control how the statements of a script are run
+type ScriptOptions struct {
+	// PrintCQL prints every statement after it is run
+	PrintCQL bool
+	// PrintConfirmation prints ok after every statement that succeeded
+	PrintConfirmation bool
+	// FailOnError stops the script at the first statement that fails
+	FailOnError bool
+}
+
+// ScriptResult sums up the run of a script
+type ScriptResult struct {
+	Statements   int
+	Failed       int
+	SyntaxErrors int
+	Elapsed      time.Duration
+}
+
+// String returns the summary printed after a script, e.g.
+// "12 statements, 1 failed, elapsed 1.204s"
+func (r ScriptResult) String() string {
+	return fmt.Sprintf("%d statements, %d failed, elapsed %v", r.Statements, r.Failed, r.Elapsed.Round(time.Millisecond))
+}
+
+// ExitCode returns the exit code of the shell after the script
+func (r ScriptResult) ExitCode() int {
+	switch {
+	case r.Failed == 0:
+		return ExitOK
+	case r.SyntaxErrors > 0:
+		return ExitSyntaxError
+	case r.Failed >= r.Statements:
+		return ExitExecutionError
+	default:
+		return ExitPartialFailure
+	}
+}
+
+// isSyntaxError reports whether the server rejected a statement as invalid
+// CQL
+func isSyntaxError(err error) bool {
+	var reqErr gocql.RequestError
+	return errors.As(err, &reqErr) && reqErr.Code() == gocql.ErrCodeSyntax
+}
+
+// ProcessScriptFile runs the statements of a file
+func ProcessScriptFile(scriptFile string, cks *db.CQLKeyspaceSession, opts ScriptOptions) (ScriptResult, error) {
+	f, err := os.Open(scriptFile)
 	if err != nil {
-		fmt.Printf("error opening file %s: %v\n", scriptFile, err)
-		os.Exit(-2)
+		return ScriptResult{}, fmt.Errorf("error opening file %s: %v", scriptFile, err)
 	}
+	defer f.Close()
+	return ProcessScript(f, cks, opts), nil
+}
 
-	r := bufio.NewReader(file)
-	for {
-		cql, e := r.ReadString(';')
-		if e != nil {
-			break
-		}
-		breakLoop, continueLoop, err := action.ProcessCommand(cql, cks)
-		if printCQL {
-			fmt.Println(cql)
+// ProcessScript runs the statements read from r as they are completed, so
+// a piped stream does not have to end before the first one is run
+func ProcessScript(r io.Reader, cks *db.CQLKeyspaceSession, opts ScriptOptions) ScriptResult {
+	start := time.Now()
+	var result ScriptResult
+	// run executes statements and reports whether the script has to stop
+	run := func(stmts []string) bool {
+		for _, cql := range stmts {
+			breakLoop, continueLoop, err := action.ProcessCommand(cql, cks)
+			if opts.PrintCQL {
+				fmt.Println(cql)
+			}
+			if breakLoop {
+				return true
+			}
+			if continueLoop {
+				continue
+			}
+			result.Statements++
+			if err == nil {
+				if opts.PrintConfirmation {
+					fmt.Println("ok")
+				}
+				continue
+			}
+			// statement errors stay out of machine readable output
+			fmt.Fprintln(os.Stderr, err)
+			result.Failed++
+			if isSyntaxError(err) {
+				result.SyntaxErrors++
+			}
+			if opts.FailOnError {
+				return true
+			}
 		}
-		if breakLoop {
+		return false
+	}
+
+	reader := bufio.NewReader(r)
+	var input strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		input.WriteString(line)
+		if err == io.EOF {
 			break
 		}
-		if continueLoop {
-			continue
-		}
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintf(os.Stderr, "error reading script: %v\n", err)
+			result.Failed++
+			result.Elapsed = time.Since(start)
+			return result
 		}
-
-		if err != nil && failOnError {
-			os.Exit(-1)
+		stmts, rest := lexer.Split(input.String())
+		input.Reset()
+		input.WriteString(rest)
+		if run(stmts) {
+			result.Elapsed = time.Since(start)
+			return result
 		}
 	}
+
+	stmts, rest := lexer.Split(input.String())
+	// the last statement does not need a terminating semicolon
+	if last := lexer.Remainder(rest); last != "" {
+		stmts = append(stmts, last)
+	}
+	run(stmts)
+	result.Elapsed = time.Since(start)
+	return result
 }
@@ -0,0 +1,71 @@
+package runtime
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/npenkov/gcqlsh/internal/db"
+)
+
+func TestProcessScript(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		failOnError bool
+		statements  int
+		failed      int
+		format      string
+	}{
+		{"all succeed", "FORMAT csv;\nEXPAND ON", false, 2, 0, "csv"},
+		{"statement spans lines", "FORMAT\njson\n;", false, 1, 0, "json"},
+		{"failures are counted", "FORMAT nope; FORMAT csv;\nFORMAT bad;", false, 3, 2, "csv"},
+		{"fail on error stops", "FORMAT nope;\nFORMAT csv;", true, 1, 1, ""},
+		{"exit stops", "FORMAT csv; EXIT; FORMAT nope;", false, 1, 0, "csv"},
+		{"comments only", "-- nothing to run\n", false, 0, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cks := &db.CQLKeyspaceSession{}
+			result := ProcessScript(strings.NewReader(tt.script), cks, ScriptOptions{FailOnError: tt.failOnError})
+			if result.Statements != tt.statements {
+				t.Errorf("Expected %d statements, got: %d", tt.statements, result.Statements)
+			}
+			if result.Failed != tt.failed {
+				t.Errorf("Expected %d failed statements, got: %d", tt.failed, result.Failed)
+			}
+			if cks.Format != tt.format {
+				t.Errorf("Expected format %q, got: %q", tt.format, cks.Format)
+			}
+		})
+	}
+}
+
+func TestScriptResultExitCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		result ScriptResult
+		code   int
+	}{
+		{"no statements", ScriptResult{}, ExitOK},
+		{"all succeed", ScriptResult{Statements: 3}, ExitOK},
+		{"all fail", ScriptResult{Statements: 2, Failed: 2}, ExitExecutionError},
+		{"some fail", ScriptResult{Statements: 3, Failed: 1}, ExitPartialFailure},
+		{"syntax error", ScriptResult{Statements: 3, Failed: 2, SyntaxErrors: 1}, ExitSyntaxError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := tt.result.ExitCode(); code != tt.code {
+				t.Errorf("Expected exit code %d, got: %d", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestScriptResultString(t *testing.T) {
+	result := ScriptResult{Statements: 12, Failed: 1, Elapsed: 1204300 * time.Microsecond}
+	expected := "12 statements, 1 failed, elapsed 1.204s"
+	if s := result.String(); s != expected {
+		t.Errorf("Expected %q, got: %q", expected, s)
+	}
+}